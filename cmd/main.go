@@ -2,15 +2,28 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/deadrootsec/goscout/pkg/goscout"
 	"github.com/deadrootsec/goscout/pkg/llm"
+	"github.com/deadrootsec/goscout/pkg/patterns"
 	"github.com/deadrootsec/goscout/pkg/report"
 	"github.com/deadrootsec/goscout/pkg/scanner"
+	"github.com/deadrootsec/goscout/pkg/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -19,22 +32,103 @@ var (
 
 var (
 	// Flags
-	versionFlag   bool
-	showPatterns  bool
-	secretsScan   bool
-	secretsWithAI bool
-	logAIPath     string
-	format        string
-	maxFileSize   int64
-	excludeDirs   []string
-	excludeFiles  []string
-	severity      string
-	jsonOutput    bool
-	defaultModel  string
-	chunkLines    int
-	ollamaURL     string
-	enableAI      bool
-	aiAnalyzeEach bool
+	versionFlag     bool
+	quiet           bool
+	noColor         bool
+	showPatterns    bool
+	secretsScan     bool
+	secretsWithAI   bool
+	logAIPath       string
+	format          string
+	maxSize         string
+	maxFileSize     int64
+	maxLine         string
+	maxLineLength   int64
+	excludeDirs     []string
+	excludeFiles    []string
+	excludeGlobs    []string
+	includeExts     []string
+	scanArchives    bool
+	verifyMatches   bool
+	dedupMatches    bool
+	multilineAssign bool
+	maxMatches      int
+	followSymlinks  bool
+	proximity       int
+	keywords        []string
+	ignoreFile      string
+	severity        string
+	minSeverity     string
+	jsonOutput      bool
+	defaultModel    string
+	chunkLines      int
+	maxLogSize      string
+	rollup          bool
+	rollupOnly      bool
+	ollamaURL       string
+	enableAI        bool
+	aiAnalyzeEach   bool
+	failFast        bool
+	showStats       bool
+	concurrency     int
+	gitignore       bool
+	entropy         float64
+	baselinePath    string
+	writeBaseline   string
+	diffPath        string
+	listFixed       bool
+	noLineContent   bool
+	noMatchValue    bool
+	aiStream        bool
+	retries         int
+	listModels      bool
+	pullModel       bool
+	redactBeforeAI  bool
+	outputPath      string
+	aiOutputPath    string
+	promptFile      string
+	dryRun          bool
+	pii             bool
+	network         bool
+	readStdin       bool
+	stagedOnly      bool
+	gitHistory      bool
+	historySince    string
+	aiEachMax       int
+	contextLines    int
+	failOn          string
+	exitCodeMode    string
+	exitCodes       map[string]int
+	groupBy         string
+	summaryOnly     bool
+	showSecrets     bool
+	configPath      string
+	onlyPatterns    []string
+	disablePatterns []string
+	categories      []string
+	grepPattern     string
+	patternFiles    []string
+	strictPatterns  bool
+	customPatterns  []patterns.Pattern
+	requestTimeout  time.Duration
+	fileTimeout     time.Duration
+	stream          bool
+	skipGenerated   bool
+	webhookURL      string
+	relativePaths   bool
+	ndjsonInput     bool
+	webhookHeaders  []string
+	genMarkers      []string
+	temperature     float64
+	topP            float64
+	numCtx          int
+	apiType         string
+	cacheDir        string
+	noCache         bool
+
+	// flagChanged reports whether a flag was given on the command line. It is bound in init
+	// because rootCmd cannot be referenced from the functions it runs.
+	flagChanged func(name string) bool
 )
 
 var rootCmd = &cobra.Command{
@@ -46,35 +140,119 @@ All processing happens locally without sending data to external services.
 Examples:
   goscout --secrets
   goscout --secrets /path/to/repo
+  goscout --secrets ./service-a ./service-b
   goscout --secrets /path/to/repo --ai
+  git diff --cached | goscout --secrets -
+  goscout --secrets --staged
+  goscout --secrets --git-history --since v1.0.0
   goscout --logai /path/to/log.txt
   goscout --list-patterns
+  goscout completion bash > /etc/bash_completion.d/goscout
   goscout --version`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(configPath, flagChanged("config"))
+		if err != nil {
+			return err
+		}
+		applyConfig(cfg, flagChanged)
+
+		if noColor {
+			color.NoColor = true
+		}
+		console.quiet = quiet
+
 		if versionFlag {
 			fmt.Printf("GoScout version %s\n", version)
 			return nil
 		}
 
+		customPatterns = nil
+		if len(patternFiles) > 0 {
+			loaded, warnings, err := patterns.LoadPaths(patternFiles, strictPatterns)
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				console.Warnf("⚠️  %s\n", warning)
+			}
+			customPatterns = loaded
+		}
+
 		if showPatterns {
-			report.PrintPatterns()
+			report.PrintPatterns(os.Stdout, patterns.Merge(goscout.Patterns(pii, network), customPatterns))
 			return nil
 		}
 
+		if grepPattern != "" {
+			matched := patterns.GetPatternsByName(grepPattern)
+			if len(matched) == 0 {
+				console.Warnf("No patterns match %q\n", grepPattern)
+				return nil
+			}
+			report.PrintPatterns(os.Stdout, matched)
+			return nil
+		}
+
+		if listModels {
+			return printModels()
+		}
+
 		if logAIPath != "" {
 			return analyzeLogWithAI(logAIPath)
 		}
 
 		if secretsScan {
-			scanPath := "."
-			if len(args) > 0 {
-				scanPath = args[0]
+			if failOn != "none" && patterns.SeverityRank(failOn) == 0 {
+				return fmt.Errorf("invalid --fail-on value %q: use none, low, medium or high", failOn)
+			}
+
+			codes, err := parseExitCodes(exitCodeMode)
+			if err != nil {
+				return fmt.Errorf("invalid --exit-code-mode value: %w", err)
+			}
+			exitCodes = codes
+
+			size, err := utils.ParseBytes(maxSize)
+			if err != nil {
+				return fmt.Errorf("invalid --max-size value: %w", err)
+			}
+			maxFileSize = size
+
+			if maxLineLength, err = utils.ParseBytes(maxLine); err != nil {
+				return fmt.Errorf("invalid --max-line-length value: %w", err)
+			}
+
+			if minSeverity != "" && patterns.SeverityRank(minSeverity) == 0 {
+				return fmt.Errorf("invalid --min-severity value %q: use low, medium or high", minSeverity)
+			}
+
+			if groupBy != report.GroupByFile && groupBy != report.GroupByPattern {
+				return fmt.Errorf("invalid --group-by value %q: use %s or %s", groupBy, report.GroupByFile, report.GroupByPattern)
+			}
+
+			if _, err := newWebhook(); err != nil {
+				return err
+			}
+
+			if stream {
+				if enableAI || aiAnalyzeEach || dryRun || writeBaseline != "" || diffPath != "" || webhookURL != "" {
+					return fmt.Errorf("--stream cannot be combined with --ai, --ai-each, --dry-run, --write-baseline, --diff or --webhook")
+				}
+				if flagChanged("format") && format != "json" {
+					return fmt.Errorf("--stream writes JSON lines and cannot be combined with --format %s", format)
+				}
 			}
 
-			if enableAI {
-				return performSecretsWithAI(scanPath)
+			if ndjsonInput && dryRun {
+				return fmt.Errorf("--dry-run lists files on disk and cannot be combined with --ndjson")
+			}
+			if dryRun {
+				return performDryRun(args)
 			}
-			return performSecretsScan(scanPath)
+			if enableAI || aiAnalyzeEach {
+				return performSecretsWithAI(args)
+			}
+			return performSecretsScan(args)
 		}
 
 		return cmd.Help()
@@ -82,21 +260,142 @@ Examples:
 }
 
 func init() {
+	flagChanged = rootCmd.Flags().Changed
+
+	rootCmd.Flags().StringVar(&configPath, "config", configFileName, "Project config file; flags given on the command line override its values")
 	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "Show version")
 	rootCmd.Flags().BoolVar(&showPatterns, "list-patterns", false, "List all available secret patterns")
+	rootCmd.Flags().StringVar(&grepPattern, "grep-pattern", "", "List the patterns whose name contains this text, ignoring case")
+	rootCmd.Flags().BoolVar(&listModels, "list-models", false, "List models available on the Ollama server")
 	rootCmd.Flags().BoolVar(&secretsScan, "secrets", false, "Scan repository for secrets")
+	rootCmd.Flags().BoolVar(&readStdin, "stdin", false, "Scan content read from standard input (same as passing -)")
+	rootCmd.Flags().BoolVar(&ndjsonInput, "ndjson", false, "Scan files read from standard input as JSON lines of {\"path\": ..., \"content\": ...}, reporting findings under each path")
+	rootCmd.Flags().BoolVar(&stagedOnly, "staged", false, "Scan only files staged for commit (for pre-commit hooks)")
+	rootCmd.Flags().BoolVar(&gitHistory, "git-history", false, "Scan every version of every file in the git history of the repository")
+	rootCmd.Flags().StringVar(&historySince, "since", "", "With --git-history, only scan commits after this revision or date (e.g. v1.2.0, 2024-01-01)")
 	rootCmd.Flags().BoolVar(&enableAI, "ai", false, "Enable AI analysis for secrets (requires Ollama)")
 	rootCmd.Flags().BoolVar(&aiAnalyzeEach, "ai-each", false, "Analyze each secret individually with AI (slower but more detailed)")
+	rootCmd.Flags().IntVar(&aiEachMax, "ai-each-max", 20, "Maximum number of findings to analyze individually with --ai-each (0 for no limit)")
+	rootCmd.Flags().BoolVar(&redactBeforeAI, "redact-before-ai", false, "Mask secret values before sending them to the model")
+	rootCmd.Flags().BoolVar(&pii, "pii", false, "Also scan for personal data: Luhn-valid card numbers, US SSNs and email addresses")
+	rootCmd.Flags().BoolVar(&network, "network", false, "Also scan for network indicators such as RFC 1918 private IP addresses")
+	rootCmd.Flags().BoolVar(&stream, "stream", false, "Write each finding as a JSON line as soon as its file is scanned, then a summary line")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the files that would be scanned and those skipped with the reason, without scanning them")
+	rootCmd.Flags().StringVar(&promptFile, "prompt-file", "", "Use a Go text/template from this file as the AI prompt; {{.Content}} receives the log chunk or findings")
+	rootCmd.Flags().BoolVar(&aiStream, "ai-stream", false, "Stream model output to stderr as it is generated")
 	rootCmd.Flags().StringVar(&logAIPath, "logai", "", "Path to log file to analyze with local LLM")
-	rootCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, table)")
-	rootCmd.Flags().Int64VarP(&maxFileSize, "max-size", "s", 10*1024*1024, "Max file size to scan in bytes (default 10MB)")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format ("+strings.Join(reportFormats, ", ")+")")
+	rootCmd.Flags().StringVarP(&maxSize, "max-size", "s", "10MB", "Max file size to scan, in bytes or with a unit (e.g. 512KB, 5.5MB)")
+	rootCmd.Flags().StringVar(&maxLine, "max-line-length", "1MB", "Longest line matched in one piece; longer lines are matched in pieces of this size")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress messages on stderr; the report and errors are still printed")
+	rootCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when not writing to a terminal)")
+	rootCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Print only the severity counts and file statistics, not each finding")
+	rootCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print secret values in full instead of masking them (for local triage)")
+	rootCmd.Flags().BoolVar(&relativePaths, "relative-paths", true, "Report files relative to the scan root and the home directory as ~ (--relative-paths=false for absolute paths)")
+	rootCmd.Flags().BoolVar(&noLineContent, "no-line-content", false, "Leave the line content out of JSON findings")
+	rootCmd.Flags().BoolVar(&noMatchValue, "no-match-value", false, "Leave the matched value out of JSON findings, keeping the fingerprint to identify them")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", report.GroupByFile, "Group findings in the text and table reports by file or pattern")
+	rootCmd.Flags().StringVar(&failOn, "fail-on", "low", "Minimum severity that makes the exit code non-zero (none, low, medium, high)")
+	rootCmd.Flags().StringVar(&exitCodeMode, "exit-code-mode", exitCodeModeAny, "Exit codes for findings: any (1 for every severity), severity (low=2, medium=3, high=4) or a mapping such as high=10,medium=5")
+	rootCmd.Flags().DurationVar(&fileTimeout, "file-timeout", scanner.DefaultFileTimeout, "Abandon a file that takes longer than this to scan and report it as an error (0 for no limit)")
+	rootCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop scanning at the first finding and exit non-zero")
+	rootCmd.Flags().BoolVar(&showStats, "stats", false, "Print scan duration, throughput and time spent matching to stderr after the scan")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", runtime.NumCPU(), "Number of files to scan, or log chunks to analyze, in parallel")
+	rootCmd.Flags().BoolVar(&gitignore, "respect-gitignore", false, "Skip files ignored by .gitignore")
+	rootCmd.Flags().Float64Var(&entropy, "entropy", 0, "Flag high entropy strings above this many bits per character (bare flag uses 4.5)")
+	rootCmd.Flags().Lookup("entropy").NoOptDefVal = strconv.FormatFloat(scanner.DefaultEntropyThreshold, 'f', -1, 64)
+	rootCmd.Flags().StringVar(&baselinePath, "baseline", "", "Suppress findings listed in this baseline file")
+	rootCmd.Flags().StringVar(&writeBaseline, "write-baseline", "", "Write current findings to a baseline file and exit")
+	rootCmd.Flags().StringVar(&webhookURL, "webhook", "", "After scanning, POST the JSON report to this URL; the local report is written either way")
+	rootCmd.Flags().StringArrayVar(&webhookHeaders, "webhook-header", nil, "Header sent with the --webhook request, e.g. \"Authorization: Bearer TOKEN\" (repeatable)")
+	rootCmd.Flags().StringVar(&diffPath, "diff", "", "Report only findings that are not in this previous JSON report")
+	rootCmd.Flags().BoolVar(&listFixed, "list-fixed", false, "With --diff, also list findings of the previous report that are gone")
+	rootCmd.Flags().IntVar(&contextLines, "context", 0, "Lines of context to show before and after each match")
+	rootCmd.Flags().StringSliceVar(&onlyPatterns, "only-patterns", nil, "Scan only with these patterns (names or IDs, comma separated)")
+	rootCmd.Flags().StringSliceVar(&disablePatterns, "disable-patterns", nil, "Patterns to leave out of the scan (names or IDs, comma separated)")
+	rootCmd.Flags().StringArrayVar(&patternFiles, "patterns-file", nil, "Load custom patterns from a YAML or JSON file, or every such file in a directory (repeatable; later files override earlier ones)")
+	rootCmd.Flags().BoolVar(&strictPatterns, "strict-patterns", false, "Fail when a pattern name is defined in more than one --patterns-file")
+	rootCmd.Flags().StringSliceVar(&categories, "category", nil, "Scan only with patterns in these categories: "+strings.Join(patterns.Categories, ", ")+" (comma separated)")
 	rootCmd.Flags().StringSliceVar(&excludeDirs, "exclude-dirs", nil, "Additional directories to exclude")
 	rootCmd.Flags().StringSliceVar(&excludeFiles, "exclude-files", nil, "Additional files to exclude")
-	rootCmd.Flags().StringVarP(&severity, "severity", "S", "", "Filter results by severity (high, medium, low)")
+	rootCmd.Flags().StringSliceVar(&excludeGlobs, "exclude-glob", nil, "Glob patterns of files or directories to exclude (e.g. '*.min.js', 'test/**/fixtures')")
+	rootCmd.Flags().StringVar(&ignoreFile, "ignore-file", "", "Gitignore-style file of paths to skip (default: .goscoutignore in the scan root)")
+	rootCmd.Flags().StringSliceVar(&includeExts, "include-ext", nil, "Only scan files with these extensions (e.g. .go,.py,.env)")
+	rootCmd.Flags().BoolVar(&skipGenerated, "skip-generated", false, "Skip generated files, marked near their top by e.g. \"Code generated ... DO NOT EDIT\"")
+	rootCmd.Flags().StringArrayVar(&genMarkers, "generated-marker", nil, "Regular expression marking a generated file for --skip-generated; repeatable, replaces the defaults and implies --skip-generated")
+	rootCmd.Flags().BoolVar(&scanArchives, "scan-archives", false, "Scan files inside .zip, .tar, .tar.gz and .tgz archives")
+	rootCmd.Flags().BoolVar(&verifyMatches, "verify", false, "Drop findings that fail structural validation, such as malformed AWS access key IDs")
+	rootCmd.Flags().IntVar(&proximity, "keyword-proximity", 0, "Keep generic and entropy findings only when a keyword is within this many characters (bare flag uses 40)")
+	rootCmd.Flags().Lookup("keyword-proximity").NoOptDefVal = strconv.Itoa(scanner.DefaultKeywordProximity)
+	rootCmd.Flags().StringSliceVar(&keywords, "keywords", scanner.DefaultProximityKeywords, "Keywords accepted by --keyword-proximity")
+	rootCmd.Flags().BoolVar(&dedupMatches, "dedup", false, "Report one finding per line and secret when several patterns match it, keeping the most severe")
+	rootCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Follow symlinked files and directories while walking (link cycles are detected)")
+	rootCmd.Flags().IntVar(&maxMatches, "max-matches-per-file", 0, "Report at most this many matches per file, noting capped files in the report (0 for no limit)")
+	rootCmd.Flags().BoolVar(&multilineAssign, "multiline-assign", false, "Also match assignments wrapped onto following lines (YAML values on the next line, shell backslash continuations)")
+	rootCmd.Flags().StringVarP(&severity, "severity", "S", "", "Show only findings of exactly this severity (high, medium, low)")
+	rootCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Show only findings of this severity or higher (low, medium, high)")
+	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the report to a file instead of stdout")
+	rootCmd.Flags().StringVar(&aiOutputPath, "ai-output", "", "Write the AI analysis to this file instead of after the secrets report (\"-\" for stdout)")
 	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output JSON format (shorthand for --format json)")
 	rootCmd.Flags().StringVar(&defaultModel, "model", llm.DefaultModel, "LLM model to use for analysis")
+	rootCmd.Flags().StringVar(&maxLogSize, "max-log-size", "0", "Analyze at most this much of a --logai log, e.g. 50MB; the rest is dropped with a warning (0 for no limit)")
+	rootCmd.Flags().BoolVar(&rollup, "rollup", false, "After the per-chunk summaries of a --logai log, ask the model for one consolidated summary")
+	rootCmd.Flags().BoolVar(&rollupOnly, "rollup-only", false, "Like --rollup, but print only the consolidated summary")
 	rootCmd.Flags().IntVar(&chunkLines, "chunk-lines", llm.DefaultChunkLines, "Lines per chunk for analysis (default 2000)")
+	rootCmd.Flags().IntVar(&retries, "retries", llm.DefaultMaxRetries, "Retries for transient Ollama failures")
+	rootCmd.Flags().BoolVar(&pullModel, "pull", false, "Pull the model from the Ollama registry if it is missing")
+	rootCmd.Flags().DurationVar(&requestTimeout, "timeout", llm.RequestTimeout, "Timeout for each request to Ollama (e.g. 90s, 10m)")
+	rootCmd.Flags().Float64Var(&temperature, "temperature", 0, "Sampling temperature; lower is more deterministic (default: model setting)")
+	rootCmd.Flags().Float64Var(&topP, "top-p", 0, "Nucleus sampling probability between 0 and 1 (default: model setting)")
+	rootCmd.Flags().IntVar(&numCtx, "num-ctx", 0, "Context window size in tokens (default: model setting)")
 	rootCmd.Flags().StringVar(&ollamaURL, "ollama-url", llm.OllamaDefaultURL, "Ollama server URL")
+	rootCmd.Flags().StringVar(&cacheDir, "cache-dir", defaultCacheDir(), "Directory where LLM responses are cached so repeated prompts are not re-sent")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Always query the model, neither reading nor writing the response cache")
+	rootCmd.Flags().StringVar(&apiType, "api-type", llm.APITypeOllama, "Server API: ollama, or openai for OpenAI-compatible servers (llama.cpp, vLLM, LM Studio)")
+
+	// Shell completion, through cobra's completion command, suggests values for flags with a fixed set
+	completeValues := func(flag string, values ...string) {
+		rootCmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	}
+	completeValues("format", reportFormats...)
+	completeValues("group-by", report.GroupByFile, report.GroupByPattern)
+	completeValues("fail-on", "none", "low", "medium", "high")
+	completeValues("severity", "high", "medium", "low")
+	completeValues("min-severity", "low", "medium", "high")
+	completeValues("exit-code-mode", exitCodeModeAny, exitCodeModeSeverity)
+	completeValues("api-type", llm.APITypeOllama, llm.APITypeOpenAI)
+	completeValues("category", patterns.Categories...)
+	rootCmd.RegisterFlagCompletionFunc("model", completeModels)
+}
+
+// logger writes progress messages to stderr, dropping informational ones in quiet mode
+type logger struct {
+	w     io.Writer
+	quiet bool
+}
+
+// console is the logger for all progress output
+var console = &logger{w: os.Stderr}
+
+// Infof prints an informational progress message unless quiet mode is on
+func (l *logger) Infof(format string, args ...interface{}) {
+	if l.quiet {
+		return
+	}
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// Warnf prints a warning, even in quiet mode
+func (l *logger) Warnf(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// writer returns the destination for informational output such as download progress
+func (l *logger) writer() io.Writer {
+	if l.quiet {
+		return io.Discard
+	}
+	return l.w
 }
 
 func main() {
@@ -106,185 +405,985 @@ func main() {
 	}
 }
 
-func performSecretsScan(scanPath string) error {
+func performSecretsScan(scanPaths []string) error {
 	if jsonOutput {
 		format = "json"
 	}
 
-	if _, err := os.Stat(scanPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", scanPath)
+	targets, err := resolveScanTargets(scanPaths)
+	if err != nil {
+		return err
 	}
 
-	absPath, err := filepath.Abs(scanPath)
+	console.Infof("🔍 Scanning: %s\n", strings.Join(targets, ", "))
+	if stream {
+		console.Infof("📋 Format: json lines, streamed\n\n")
+	} else {
+		console.Infof("📋 Format: %s\n\n", format)
+	}
+
+	sc, err := goscout.NewScanner(scanOptions())
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return fmt.Errorf("❌ %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "🔍 Scanning: %s\n", absPath)
-	fmt.Fprintf(os.Stderr, "📋 Format: %s\n\n", format)
+	if stream {
+		return streamSecretsScan(sc, targets)
+	}
 
-	sc := scanner.NewScanner()
-	sc.SetMaxFileSize(maxFileSize)
+	results, err := scanTargets(sc, targets)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	printStats(results.Stats)
 
-	for _, dir := range excludeDirs {
-		sc.AddExcludeDir(dir)
+	if writeBaseline != "" {
+		return saveBaseline(writeBaseline, results.Matches)
 	}
 
-	for _, file := range excludeFiles {
-		sc.AddExcludeFile(file)
+	if results.Matches, err = applyBaseline(results.Matches); err != nil {
+		return err
 	}
 
-	results, err := sc.ScanPath(absPath)
-	if err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+	var fixed []*report.MatchReport
+	if results.Matches, fixed, err = applyDiff(results.Matches); err != nil {
+		return err
 	}
 
-	if severity != "" {
-		filtered := make([]*scanner.Match, 0)
-		for _, match := range results.Matches {
-			if match.Pattern.Severity == severity {
-				filtered = append(filtered, match)
-			}
-		}
-		results.Matches = filtered
+	results.Matches = goscout.FilterSeverity(results.Matches, severity, minSeverity)
+
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
 	}
 
-	rpt := report.NewReport(os.Stdout, format)
+	rpt := report.NewReport(out, format)
+	rpt.SetVersion(version)
+	rpt.SetRoots(targets)
+	rpt.SetGroupBy(groupBy)
+	rpt.SetSummaryOnly(summaryOnly)
+	rpt.SetShowSecrets(showSecrets)
+	rpt.SetRelativePaths(relativePaths)
+	rpt.SetOmitLineContent(noLineContent)
+	rpt.SetOmitMatchValue(noMatchValue)
+	rpt.SetErrors(results.Errors)
+	rpt.SetCappedFiles(results.CappedFiles, maxMatches)
+	rpt.SetFixed(fixed)
 	if err := rpt.GenerateSecrets(results.Matches, results.FilesScanned, results.FilesSkipped); err != nil {
+		closeOutput(out)
 		return fmt.Errorf("failed to generate report: %w", err)
 	}
 
-	if len(results.Matches) > 0 {
-		os.Exit(1)
+	if err := closeOutput(out); err != nil {
+		return err
+	}
+
+	if err := postWebhook(rpt, results); err != nil {
+		return err
+	}
+
+	if code := exitCode(results.Matches, failOn, exitCodes); code != 0 {
+		os.Exit(code)
+	}
+
+	return nil
+}
+
+// newWebhook returns the --webhook sink with its --webhook-header headers, or nil when no URL is set
+func newWebhook() (*report.Webhook, error) {
+	if webhookURL == "" {
+		return nil, nil
+	}
+
+	hook := report.NewWebhook(webhookURL)
+	for _, header := range webhookHeaders {
+		if err := hook.AddHeader(header); err != nil {
+			return nil, err
+		}
+	}
+	return hook, nil
+}
+
+// postWebhook posts the JSON form of a secrets report to --webhook. It runs after the local report is
+// written, so a failing endpoint doesn't lose it.
+func postWebhook(rpt *report.Report, results *scanner.ScanResult) error {
+	hook, err := newWebhook()
+	if hook == nil || err != nil {
+		return err
+	}
+
+	if err := hook.PostSecrets(rpt, results.Matches, results.FilesScanned, results.FilesSkipped); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	console.Infof("📤 Report posted to %s\n", webhookURL)
+	return nil
+}
+
+// streamSecretsScan writes each finding as a JSON line as soon as its file is scanned, ending with a
+// summary line. The baseline and severity filters are applied to each finding as it arrives.
+func streamSecretsScan(sc *scanner.Scanner, targets []string) error {
+	var baseline *report.Baseline
+	if baselinePath != "" {
+		var err error
+		if baseline, err = report.LoadBaseline(baselinePath); err != nil {
+			return err
+		}
+	}
+
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
+	}
+
+	rpt := report.NewReport(out, "json")
+	rpt.SetShowSecrets(showSecrets)
+	rpt.SetRelativePaths(relativePaths)
+	rpt.SetOmitLineContent(noLineContent)
+	rpt.SetOmitMatchValue(noMatchValue)
+	lines := rpt.Stream()
+
+	// One finding per severity is enough to pick the exit code
+	var writeErr error
+	worst := make(map[string]*scanner.Match)
+	sc.SetMatchHandler(func(match *scanner.Match) {
+		if baseline != nil && baseline.Contains(match) {
+			return
+		}
+		if len(goscout.FilterSeverity([]*scanner.Match{match}, severity, minSeverity)) == 0 {
+			return
+		}
+		if writeErr == nil {
+			writeErr = lines.ReportMatch(match)
+		}
+		worst[strings.ToLower(match.Pattern.Severity)] = match
+	})
+
+	results, err := scanTargets(sc, targets)
+	if err != nil {
+		closeOutput(out)
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	printStats(results.Stats)
+
+	rpt.SetErrors(results.Errors)
+	rpt.SetCappedFiles(results.CappedFiles, maxMatches)
+	if writeErr == nil {
+		writeErr = lines.Finish(results.FilesScanned, results.FilesSkipped)
+	}
+	if writeErr != nil {
+		closeOutput(out)
+		return fmt.Errorf("failed to generate report: %w", writeErr)
+	}
+
+	if err := closeOutput(out); err != nil {
+		return err
+	}
+
+	found := make([]*scanner.Match, 0, len(worst))
+	for _, match := range worst {
+		found = append(found, match)
+	}
+	if code := exitCode(found, failOn, exitCodes); code != 0 {
+		os.Exit(code)
 	}
 
 	return nil
 }
 
-func performSecretsWithAI(scanPath string) error {
+// performDryRun lists the files a secrets scan would read and those it would skip, without scanning them
+func performDryRun(scanPaths []string) error {
 	if jsonOutput {
 		format = "json"
 	}
 
-	if _, err := os.Stat(scanPath); os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", scanPath)
+	targets, err := resolveScanTargets(scanPaths)
+	if err != nil {
+		return err
+	}
+	if gitHistory || (len(targets) == 1 && targets[0] == scanner.StdinName) {
+		return fmt.Errorf("--dry-run lists files on disk and cannot be used with standard input or --git-history")
+	}
+
+	sc, err := goscout.NewScanner(scanOptions())
+	if err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	result, err := sc.DryRun(context.Background(), targets)
+	if err != nil {
+		return fmt.Errorf("dry run failed: %w", err)
+	}
+
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
+	}
+
+	rpt := report.NewReport(out, format)
+	if err := rpt.GenerateDryRun(result); err != nil {
+		closeOutput(out)
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	return closeOutput(out)
+}
+
+func performSecretsWithAI(scanPaths []string) error {
+	if jsonOutput {
+		format = "json"
 	}
 
-	absPath, err := filepath.Abs(scanPath)
+	targets, err := resolveScanTargets(scanPaths)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "🔍 Scanning: %s\n", absPath)
-	fmt.Fprintf(os.Stderr, "🤖 AI Analysis: ENABLED\n")
-	fmt.Fprintf(os.Stderr, "📋 Format: %s\n\n", format)
+	console.Infof("🔍 Scanning: %s\n", strings.Join(targets, ", "))
+	console.Infof("🤖 AI Analysis: ENABLED\n")
+	console.Infof("📋 Format: %s\n\n", format)
 
 	// Initialize analyzer
-	analyzer := llm.NewAnalyzer()
-	analyzer.SetModel(defaultModel)
-	analyzer.SetOllamaURL(ollamaURL)
-	analyzer.SetChunkLines(chunkLines)
+	analyzer, err := newAnalyzer()
+	if err != nil {
+		return err
+	}
 
-	fmt.Fprintf(os.Stderr, "⏳ Checking Ollama connection...\n")
+	console.Infof("⏳ Checking Ollama connection...\n")
 	if err := analyzer.HealthCheck(); err != nil {
 		return fmt.Errorf("❌ %w\nMake sure Ollama is running: ollama serve", err)
 	}
 
-	// Initialize scanner
-	sc := scanner.NewScanner()
-	sc.SetMaxFileSize(maxFileSize)
-	sc.SetAnalyzer(analyzer)
-
-	for _, dir := range excludeDirs {
-		sc.AddExcludeDir(dir)
+	if err := ensureModel(analyzer); err != nil {
+		return err
 	}
 
-	for _, file := range excludeFiles {
-		sc.AddExcludeFile(file)
+	// Initialize scanner
+	sc, err := goscout.NewScanner(scanOptions())
+	if err != nil {
+		return fmt.Errorf("❌ %w", err)
 	}
+	sc.SetRedactBeforeAI(redactBeforeAI)
+	sc.SetAnalyzer(analyzer)
 
 	// Perform initial scan
-	fmt.Fprintf(os.Stderr, "📊 Performing initial secret scan...\n")
-	results, err := sc.ScanPath(absPath)
+	console.Infof("📊 Performing initial secret scan...\n")
+	results, err := scanTargets(sc, targets)
 	if err != nil {
 		return fmt.Errorf("scan failed: %w", err)
 	}
+	printStats(results.Stats)
+
+	if writeBaseline != "" {
+		return saveBaseline(writeBaseline, results.Matches)
+	}
+
+	if results.Matches, err = applyBaseline(results.Matches); err != nil {
+		return err
+	}
+
+	var fixed []*report.MatchReport
+	if results.Matches, fixed, err = applyDiff(results.Matches); err != nil {
+		return err
+	}
 
 	if len(results.Matches) == 0 {
-		fmt.Fprintf(os.Stderr, "✅ No secrets found!\n")
+		console.Infof("✅ No secrets found!\n")
 		return nil
 	}
 
-	fmt.Fprintf(os.Stderr, "🔍 Found %d potential secrets\n", len(results.Matches))
+	console.Infof("🔍 Found %d potential secrets\n", len(results.Matches))
 
 	// Filter by severity if requested
-	if severity != "" {
-		filtered := make([]*scanner.Match, 0)
-		for _, match := range results.Matches {
-			if match.Pattern.Severity == severity {
-				filtered = append(filtered, match)
-			}
-		}
-		results.Matches = filtered
-		fmt.Fprintf(os.Stderr, "🔽 Filtered to %d secrets with severity: %s\n", len(results.Matches), severity)
+	if severity != "" || minSeverity != "" {
+		results.Matches = goscout.FilterSeverity(results.Matches, severity, minSeverity)
+		console.Infof("🔽 Filtered to %d secrets by severity\n", len(results.Matches))
 	}
 
 	if len(results.Matches) == 0 {
-		fmt.Fprintf(os.Stderr, "✅ No secrets found matching severity filter!\n")
+		console.Infof("✅ No secrets found matching severity filter!\n")
 		return nil
 	}
 
 	// Perform AI analysis
-	fmt.Fprintf(os.Stderr, "\n🤖 Analyzing secrets with AI...\n")
-	fmt.Fprintf(os.Stderr, "⏳ Querying %s model...\n\n", analyzer.Model)
+	console.Infof("\n🤖 Analyzing secrets with AI...\n")
+	console.Infof("⏳ Querying %s model...\n\n", analyzer.Model)
+
+	if aiAnalyzeEach {
+		return reportEachFinding(sc, analyzer, targets, results, fixed)
+	}
 
-	// Format all matches for comprehensive analysis
-	allSecretsContext := formatAllSecretsForAnalysis(results.Matches)
+	// Ctrl-C stops the analysis, but the findings and any analysis that finished are still written
+	ctx, stop := interruptContext()
+	defer stop()
+	analysisReport, err := analyzeAllSecrets(ctx, analyzer, results.Matches)
+	interrupted := ctx.Err() != nil && errors.Is(err, context.Canceled)
+	if err != nil && !interrupted {
+		return err
+	}
 
-	// Get comprehensive analysis
-	fmt.Fprintf(os.Stderr, "📋 Generating comprehensive analysis...\n")
-	analysisPrompt := llm.ComprehensiveSecretsAnalysisPrompt(allSecretsContext)
-	analysisResult, err := analyzer.Query(analysisPrompt)
-	if err != nil {
-		return fmt.Errorf("❌ Analysis failed: %w", err)
+	var analyses []*report.AnalysisReport
+	if analysisReport != nil {
+		analyses = append(analyses, analysisReport)
+	}
+	if interrupted {
+		console.Warnf("\n⚠️  Interrupted: writing the findings without a complete AI analysis\n")
+	}
+
+	// Output the analysis
+	banner := ""
+	if format != "markdown" && format != "json" {
+		banner = "=== AI SECURITY ANALYSIS RESUME ===\n\n"
 	}
+	if err := writeAIReport(targets, results, fixed, analyses, banner); err != nil {
+		return err
+	}
+
+	if interrupted {
+		return fmt.Errorf("❌ Analysis interrupted, %d findings written without a complete analysis", len(results.Matches))
+	}
+
+	console.Infof("✅ Analysis complete\n")
+
+	if code := exitCode(results.Matches, failOn, exitCodes); code != 0 {
+		os.Exit(code)
+	}
+
+	return nil
+}
 
-	// Generate resume/summary from the analysis
-	fmt.Fprintf(os.Stderr, "📝 Generating security resume...\n")
-	resumePrompt := llm.SecretsResumePrompt(analysisResult.Findings)
-	resumeResult, err := analyzer.Query(resumePrompt)
+// analyzeAllSecrets analyzes all findings together and, for the built-in prompt, summarizes the analysis
+// in a resume. When the resume is interrupted, the analysis is returned on its own along with the error.
+func analyzeAllSecrets(ctx context.Context, analyzer *llm.Analyzer, matches []*scanner.Match) (*report.AnalysisReport, error) {
+	allSecretsContext := formatAllSecretsForAnalysis(matches)
+
+	console.Infof("📋 Generating comprehensive analysis...\n")
+	analysisPrompt, err := analyzer.Prompt(llm.PromptData{Content: allSecretsContext}, llm.ComprehensiveSecretsAnalysisPrompt(allSecretsContext))
 	if err != nil {
-		return fmt.Errorf("❌ Resume generation failed: %w", err)
+		return nil, fmt.Errorf("❌ %w", err)
+	}
+	analysisResult, err := queryAI(ctx, analyzer, analysisPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Analysis failed: %w", err)
 	}
 
-	// Create comprehensive report
 	analysisReport := &report.AnalysisReport{
-		Title:    "AI-Powered Secrets Security Analysis Report",
-		Model:    analyzer.Model,
-		Content:  resumeResult.Findings,
-		Duration: fmt.Sprintf("Analysis: %v, Resume: %v", analysisResult.Duration, resumeResult.Duration),
+		Title:     "AI-Powered Secrets Security Analysis Report",
+		Model:     analyzer.Model,
+		Content:   analysisResult.Findings,
+		Duration:  fmt.Sprintf("Analysis: %v", analysisResult.Duration),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	analysisReport.PromptTokens, analysisReport.EvalTokens, analysisReport.TokensPerSec = llm.TotalUsage(analysisResult)
+
+	// A custom prompt already asks for the output wanted, so only the built-in analysis is summarized
+	if analyzer.Template != nil {
+		return analysisReport, nil
+	}
+
+	console.Infof("📝 Generating security resume...\n")
+	resumeResult, err := queryAI(ctx, analyzer, llm.SecretsResumePrompt(analysisResult.Findings))
+	if err != nil {
+		return analysisReport, fmt.Errorf("❌ Resume generation failed: %w", err)
+	}
+
+	analysisReport.Content = resumeResult.Findings
+	analysisReport.Duration = fmt.Sprintf("Analysis: %v, Resume: %v", analysisResult.Duration, resumeResult.Duration)
+	analysisReport.PromptTokens, analysisReport.EvalTokens, analysisReport.TokensPerSec = llm.TotalUsage(analysisResult, resumeResult)
+	return analysisReport, nil
+}
+
+// reportEachFinding analyzes every finding on its own, with the code around it, and prints
+// each analysis after the secrets report
+func reportEachFinding(sc *scanner.Scanner, analyzer *llm.Analyzer, targets []string, results *scanner.ScanResult, fixed []*report.MatchReport) error {
+	sc.SetMaxAnalyses(aiEachMax)
+
+	console.Infof("📋 Analyzing findings individually...\n")
+	analyzed := sc.AnalyzeMatches(results.Matches)
+
+	for _, err := range analyzed.AnalysisErrors {
+		console.Warnf("⚠️  %v\n", err)
+	}
+
+	if analyzed.AnalysesSkipped > 0 {
+		console.Infof("ℹ️  Stopped after %d analyses, %d findings were not analyzed (raise --ai-each-max to include them)\n",
+			aiEachMax, analyzed.AnalysesSkipped)
+	}
+
+	var analyses []*report.AnalysisReport
+	for _, analyzedMatch := range analyzed.AnalyzedMatches {
+		match := analyzedMatch.Match
+		analysisReport := &report.AnalysisReport{
+			Title:        fmt.Sprintf("%s at %s:%d", match.Pattern.Name, match.FilePath, match.LineNumber),
+			Model:        analyzer.Model,
+			Content:      analyzedMatch.Analysis.Findings,
+			Duration:     analyzedMatch.Analysis.Duration.String(),
+			Timestamp:    time.Now().UTC().Format(time.RFC3339),
+			PromptTokens: analyzedMatch.Analysis.PromptTokens,
+			EvalTokens:   analyzedMatch.Analysis.EvalTokens,
+			TokensPerSec: analyzedMatch.Analysis.TokensPerSec,
+		}
+		analyses = append(analyses, analysisReport)
+	}
+
+	if err := writeAIReport(targets, results, fixed, analyses, ""); err != nil {
+		return err
+	}
+
+	console.Infof("✅ Analysis complete\n")
+
+	if code := exitCode(results.Matches, failOn, exitCodes); code != 0 {
+		os.Exit(code)
+	}
+
+	return nil
+}
+
+// writeAIReport writes the secrets report followed by the AI analyses. With --ai-output the analyses go
+// to their own file instead, so the findings in --output stay machine-readable. banner precedes the
+// analyses when they share the output.
+func writeAIReport(targets []string, results *scanner.ScanResult, fixed []*report.MatchReport, analyses []*report.AnalysisReport, banner string) error {
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
+	}
+
+	rpt := report.NewReport(out, format)
+	rpt.SetVersion(version)
+	rpt.SetRoots(targets)
+	rpt.SetGroupBy(groupBy)
+	rpt.SetSummaryOnly(summaryOnly)
+	rpt.SetShowSecrets(showSecrets)
+	rpt.SetRelativePaths(relativePaths)
+	rpt.SetOmitLineContent(noLineContent)
+	rpt.SetOmitMatchValue(noMatchValue)
+	rpt.SetErrors(results.Errors)
+	rpt.SetCappedFiles(results.CappedFiles, maxMatches)
+	rpt.SetFixed(fixed)
+	secretsReport := rpt
+
+	separate := aiOutputPath != ""
+
+	// HTML, JSON, SARIF and JUnit reports are a single document, so the analyses are embedded in them
+	// rather than appended
+	embedded := format == "html" || format == "json" || format == "sarif" || format == "junit"
+	if embedded && !separate {
+		for _, analysisReport := range analyses {
+			rpt.AddAnalysis(analysisReport)
+		}
+		analyses = nil
 	}
 
-	// Output the analysis
-	rpt := report.NewReport(os.Stdout, format)
 	if err := rpt.GenerateSecrets(results.Matches, results.FilesScanned, results.FilesSkipped); err != nil {
+		closeOutput(out)
 		return fmt.Errorf("failed to generate report: %w", err)
 	}
 
-	fmt.Fprintf(os.Stdout, "\n=== AI SECURITY ANALYSIS RESUME ===\n\n")
-	if err := rpt.GenerateAnalysis(analysisReport); err != nil {
+	if separate {
+		if err := closeOutput(out); err != nil {
+			return err
+		}
+		if out, err = openOutput(aiOutputPath); err != nil {
+			return err
+		}
+		rpt = report.NewReport(out, format)
+		rpt.SetVersion(version)
+		rpt.SetRoots(targets)
+	} else if len(analyses) > 0 {
+		fmt.Fprintf(out, "\n%s", banner)
+	}
+
+	if err := rpt.GenerateAnalyses(analyses); err != nil {
+		closeOutput(out)
 		return fmt.Errorf("failed to generate analysis report: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "✅ Analysis complete\n")
+	if err := closeOutput(out); err != nil {
+		return err
+	}
+	return postWebhook(secretsReport, results)
+}
+
+// defaultCacheDir returns the per-user cache location for LLM responses, or "" when there is none
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "goscout", "llm")
+}
 
-	if len(results.Matches) > 0 {
-		os.Exit(1)
+// newAnalyzer creates an analyzer configured from the model, server, retry, generation, prompt and
+// cache flags
+func newAnalyzer() (*llm.Analyzer, error) {
+	analyzer := llm.NewAnalyzer()
+	analyzer.SetModel(defaultModel)
+	analyzer.SetOllamaURL(ollamaURL)
+	analyzer.SetChunkLines(chunkLines)
+	analyzer.SetMaxRetries(retries)
+	analyzer.SetTimeout(requestTimeout)
+	if err := analyzer.SetAPIType(apiType); err != nil {
+		return nil, fmt.Errorf("❌ %w", err)
+	}
+	configureGeneration(analyzer)
+	if promptFile != "" {
+		template, err := llm.LoadPromptTemplate(promptFile)
+		if err != nil {
+			return nil, fmt.Errorf("❌ %w", err)
+		}
+		analyzer.SetPromptTemplate(template)
+	}
+	if !noCache {
+		analyzer.SetCacheDir(cacheDir)
+	}
+	return analyzer, nil
+}
+
+// configureGeneration applies the sampling flags that were given on the command line
+func configureGeneration(analyzer *llm.Analyzer) {
+	if flagChanged("temperature") {
+		analyzer.SetTemperature(temperature)
+	}
+	if flagChanged("top-p") {
+		analyzer.SetTopP(topP)
+	}
+	if flagChanged("num-ctx") {
+		analyzer.SetContextWindow(numCtx)
+	}
+}
+
+// completeModels suggests the models available on the server, or nothing when it cannot be reached quickly
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	analyzer := llm.NewAnalyzer()
+	analyzer.SetOllamaURL(ollamaURL)
+	analyzer.SetTimeout(llm.HealthCheckTimeout)
+	if err := analyzer.SetAPIType(apiType); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	models, err := analyzer.ListModels()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return models, cobra.ShellCompDirectiveNoFileComp
+}
+
+// printModels lists the models pulled into Ollama
+func printModels() error {
+	analyzer := llm.NewAnalyzer()
+	analyzer.SetOllamaURL(ollamaURL)
+	analyzer.SetTimeout(requestTimeout)
+	if err := analyzer.SetAPIType(apiType); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	models, err := analyzer.ListModels()
+	if err != nil {
+		return fmt.Errorf("❌ %w\nMake sure Ollama is running: ollama serve", err)
+	}
+
+	if len(models) == 0 {
+		fmt.Println("No models available. Pull one with: ollama pull " + llm.DefaultModel)
+		return nil
+	}
+
+	fmt.Println("Available Ollama models:")
+	for _, model := range models {
+		fmt.Printf("  - %s\n", model)
+	}
+	return nil
+}
+
+// ensureModel verifies the configured model is available, pulling it when --pull is set
+func ensureModel(analyzer *llm.Analyzer) error {
+	err := analyzer.VerifyModel()
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, llm.ErrModelNotFound) {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	if !pullModel {
+		return fmt.Errorf("❌ %w\nRun with --pull to download it", err)
+	}
+
+	console.Infof("⬇️  Pulling model %s...\n", analyzer.Model)
+	if err := analyzer.PullModel(analyzer.Model, console.writer()); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	return nil
+}
+
+// interruptContext returns a context that is canceled by Ctrl-C. After the first Ctrl-C the default
+// handling is restored, so a second one exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// queryAI runs a prompt against the model, echoing tokens to stderr as they arrive when --ai-stream is set
+func queryAI(ctx context.Context, analyzer *llm.Analyzer, prompt string) (*llm.AnalysisResult, error) {
+	if !aiStream {
+		return analyzer.QueryContext(ctx, prompt)
+	}
+
+	// Streaming was asked for explicitly, so it is shown even in quiet mode
+	result, err := analyzer.QueryStreamContext(ctx, prompt, console.w)
+	fmt.Fprint(console.w, "\n\n")
+	return result, err
+}
+
+// applyBaseline drops findings recorded in the --baseline file
+func applyBaseline(matches []*scanner.Match) ([]*scanner.Match, error) {
+	if baselinePath == "" {
+		return matches, nil
+	}
+
+	baseline, err := report.LoadBaseline(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := baseline.Filter(matches)
+	console.Infof("📎 Suppressed %d findings listed in baseline\n", len(matches)-len(filtered))
+	return filtered, nil
+}
+
+// applyDiff keeps only the findings that are not in the --diff report. The fixed findings are
+// returned when --list-fixed is set.
+func applyDiff(matches []*scanner.Match) ([]*scanner.Match, []*report.MatchReport, error) {
+	if diffPath == "" {
+		return matches, nil, nil
+	}
+
+	previous, err := report.LoadJSONReport(diffPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff := report.DiffReport(previous, matches)
+	console.Infof("🆕 %d new findings, %d fixed since %s\n", len(diff.New), len(diff.Fixed), diffPath)
+	if !listFixed {
+		return diff.New, nil, nil
+	}
+	return diff.New, diff.Fixed, nil
+}
+
+// scanOptions collects the scanning flags for goscout.NewScanner
+func scanOptions() goscout.Options {
+	return goscout.Options{
+		ExcludeDirs:       excludeDirs,
+		ExcludeFiles:      excludeFiles,
+		ExcludeGlobs:      excludeGlobs,
+		IncludeExtensions: includeExts,
+		IgnoreFile:        ignoreFile,
+		MaxFileSize:       maxFileSize,
+		MaxLineLength:     int(maxLineLength),
+		Concurrency:       concurrency,
+		MaxMatchesPerFile: maxMatches,
+		ContextLines:      contextLines,
+		FileTimeout:       scanFileTimeout(),
+		RespectGitignore:  gitignore,
+		FollowSymlinks:    followSymlinks,
+		ScanArchives:      scanArchives,
+		SkipGenerated:     skipGenerated || len(genMarkers) > 0,
+		GeneratedMarkers:  genMarkers,
+		FailFast:          failFast,
+		CollectStats:      showStats,
+		EntropyThreshold:  entropy,
+		KeywordProximity:  proximity,
+		Keywords:          keywords,
+		Verify:            verifyMatches,
+		Dedup:             dedupMatches,
+		MultilineAssign:   multilineAssign,
+		OnlyPatterns:      onlyPatterns,
+		DisablePatterns:   disablePatterns,
+		PII:               pii,
+		Network:           network,
+		Categories:        categories,
+		CustomPatterns:    customPatterns,
+	}
+}
+
+// scanFileTimeout converts --file-timeout to goscout.Options, where zero means the default rather than no limit
+func scanFileTimeout() time.Duration {
+	if fileTimeout == 0 {
+		return -1
+	}
+	return fileTimeout
+}
+
+const (
+	// exitCodeModeAny exits with 1 whatever the severity of the findings, as goscout always has
+	exitCodeModeAny = "any"
+
+	// exitCodeModeSeverity exits with a code per severity, leaving 1 for errors
+	exitCodeModeSeverity = "severity"
+)
+
+// parseExitCodes turns an --exit-code-mode value into exit codes per severity. A mapping such as
+// "high=10,medium=5" overrides the codes of the severity mode for the severities it lists.
+func parseExitCodes(mode string) (map[string]int, error) {
+	if mode == exitCodeModeAny {
+		return map[string]int{"low": 1, "medium": 1, "high": 1}, nil
+	}
+
+	codes := map[string]int{"low": 2, "medium": 3, "high": 4}
+	if mode == exitCodeModeSeverity {
+		return codes, nil
+	}
+
+	for _, pair := range strings.Split(mode, ",") {
+		severity, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		severity = strings.ToLower(strings.TrimSpace(severity))
+		if !found || patterns.SeverityRank(severity) == 0 {
+			return nil, fmt.Errorf("%q: use %s, %s or severity=code pairs such as high=4,medium=3", mode, exitCodeModeAny, exitCodeModeSeverity)
+		}
+
+		code, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || code < 1 || code > 125 {
+			return nil, fmt.Errorf("exit code for %s must be between 1 and 125, got %q", severity, value)
+		}
+		codes[severity] = code
+	}
+	return codes, nil
+}
+
+// exitCode returns the code for the most severe finding that meets the --fail-on threshold, and 0
+// when there is none
+func exitCode(matches []*scanner.Match, failOn string, codes map[string]int) int {
+	if failOn == "none" {
+		return 0
+	}
+
+	threshold := patterns.SeverityRank(failOn)
+	worst := ""
+	for _, match := range matches {
+		rank := patterns.SeverityRank(match.Pattern.Severity)
+		if rank >= threshold && rank > patterns.SeverityRank(worst) {
+			worst = strings.ToLower(match.Pattern.Severity)
+		}
+	}
+
+	if worst == "" {
+		return 0
+	}
+	if code, ok := codes[worst]; ok {
+		return code
+	}
+	return 1
+}
+
+// reportFormats are the values accepted by --format
+var reportFormats = []string{"text", "json", "table", "sarif", "junit", "markdown", "html"}
+
+// configFileName is the project config file read from the current directory
+const configFileName = ".goscout.yaml"
+
+// fileConfig holds the settings a project config file can provide. Keys are named after the flags they set.
+type fileConfig struct {
+	Model           string   `yaml:"model"`
+	OllamaURL       string   `yaml:"ollama-url"`
+	Format          string   `yaml:"format"`
+	ExcludeDirs     []string `yaml:"exclude-dirs"`
+	ExcludeFiles    []string `yaml:"exclude-files"`
+	MaxSize         string   `yaml:"max-size"`
+	MinSeverity     string   `yaml:"min-severity"`
+	FailOn          string   `yaml:"fail-on"`
+	DisablePatterns []string `yaml:"disable-patterns"`
+	PatternsFile    []string `yaml:"patterns-file"`
+}
+
+// loadConfig reads a project config file. A missing file is only an error when it was named explicitly.
+func loadConfig(path string, explicit bool) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Unknown keys are rejected so a typo doesn't silently fall back to the default
+	cfg := &fileConfig{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyConfig copies config file values into the flag variables. Precedence is flag > file > default,
+// so a value is only taken from the file when its flag was not given on the command line.
+func applyConfig(cfg *fileConfig, changed func(name string) bool) {
+	if cfg == nil {
+		return
+	}
+
+	setString := func(flag string, target *string, value string) {
+		if value != "" && !changed(flag) {
+			*target = value
+		}
+	}
+	setString("model", &defaultModel, cfg.Model)
+	setString("ollama-url", &ollamaURL, cfg.OllamaURL)
+	setString("format", &format, cfg.Format)
+	setString("max-size", &maxSize, cfg.MaxSize)
+	setString("min-severity", &minSeverity, cfg.MinSeverity)
+	setString("fail-on", &failOn, cfg.FailOn)
+
+	if len(cfg.ExcludeDirs) > 0 && !changed("exclude-dirs") {
+		excludeDirs = cfg.ExcludeDirs
+	}
+	if len(cfg.ExcludeFiles) > 0 && !changed("exclude-files") {
+		excludeFiles = cfg.ExcludeFiles
+	}
+	if len(cfg.DisablePatterns) > 0 && !changed("disable-patterns") {
+		disablePatterns = cfg.DisablePatterns
+	}
+	if len(cfg.PatternsFile) > 0 && !changed("patterns-file") {
+		patternFiles = cfg.PatternsFile
+	}
+}
+
+// resolveScanTargets returns the absolute paths to scan, defaulting to the current directory,
+// scanner.StdinName alone when reading from stdin or NDJSON records, or the staged files with --staged
+func resolveScanTargets(scanPaths []string) ([]string, error) {
+	if ndjsonInput {
+		if stagedOnly || gitHistory || len(scanPaths) > 1 || (len(scanPaths) == 1 && scanPaths[0] != "-") {
+			return nil, fmt.Errorf("--ndjson reads files from standard input and takes no paths, --staged or --git-history")
+		}
+		return []string{scanner.StdinName}, nil
+	}
+
+	if stagedOnly {
+		if len(scanPaths) > 1 || readStdin {
+			return nil, fmt.Errorf("--staged takes at most one path inside the repository and cannot read standard input")
+		}
+
+		dir := "."
+		if len(scanPaths) == 1 {
+			dir = scanPaths[0]
+		}
+		return scanner.StagedFiles(dir)
+	}
+
+	if readStdin || utils.ContainsString(scanPaths, "-") {
+		if len(scanPaths) > 1 || (readStdin && len(scanPaths) > 0) {
+			return nil, fmt.Errorf("standard input cannot be scanned together with other paths")
+		}
+		return []string{scanner.StdinName}, nil
+	}
+
+	if len(scanPaths) == 0 {
+		scanPaths = []string{"."}
+	}
+
+	targets := make([]string, 0, len(scanPaths))
+	for _, scanPath := range scanPaths {
+		if _, err := os.Stat(scanPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", scanPath)
+		}
+
+		absPath, err := filepath.Abs(scanPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		targets = append(targets, absPath)
+	}
+	return targets, nil
+}
+
+// scanTargets scans standard input, NDJSON records from it with --ndjson, the git history of the target with --git-history,
+// or every resolved path into one merged result
+func scanTargets(sc *scanner.Scanner, targets []string) (*scanner.ScanResult, error) {
+	if gitHistory {
+		if len(targets) != 1 || targets[0] == scanner.StdinName {
+			return nil, fmt.Errorf("--git-history scans exactly one repository")
+		}
+		return sc.ScanGitHistory(targets[0], historySince)
+	}
+
+	if ndjsonInput {
+		return sc.ScanNDJSON(os.Stdin)
+	}
+	if len(targets) == 1 && targets[0] == scanner.StdinName {
+		return sc.ScanReader(os.Stdin, scanner.StdinName)
+	}
+	return sc.ScanPaths(targets)
+}
+
+// printStats writes the --stats summary of a scan, even in quiet mode since it was asked for
+func printStats(stats *scanner.ScanStats) {
+	if stats == nil {
+		return
+	}
+	console.Warnf("📈 Scan stats:\n")
+	console.Warnf("   Duration:   %v\n", stats.Duration.Round(time.Millisecond))
+	console.Warnf("   Files:      %d (%.1f files/s)\n", stats.Files, stats.FilesPerSecond())
+	console.Warnf("   Read:       %s (%s/s)\n", utils.FormatBytes(stats.BytesRead), utils.FormatBytes(int64(stats.BytesPerSecond())))
+	console.Warnf("   Matching:   %v\n\n", stats.MatchTime.Round(time.Millisecond))
+}
+
+// openOutput returns where a report is written: the file at path, or stdout when path is empty or "-"
+func openOutput(path string) (*os.File, error) {
+	if path == "" || path == "-" {
+		return os.Stdout, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return file, nil
+}
+
+// closeOutput closes a report file opened by openOutput, leaving stdout open
+func closeOutput(out *os.File) error {
+	if out == os.Stdout {
+		return nil
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// saveBaseline records the current findings so future scans can suppress them
+func saveBaseline(path string, matches []*scanner.Match) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create baseline: %w", err)
+	}
+	defer file.Close()
+
+	if err := report.NewReport(file, "json").GenerateBaseline(matches); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
 	}
 
+	console.Infof("📎 Wrote %d findings to baseline %s\n", len(matches), path)
 	return nil
 }
 
+// aiContext returns the line content to share with the model, masking the secret when --redact-before-ai is set
+func aiContext(match *scanner.Match) string {
+	if redactBeforeAI {
+		return match.RedactedLine()
+	}
+	return match.LineContent
+}
+
 func formatAllSecretsForAnalysis(matches []*scanner.Match) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Total Secrets Found: %d\n\n", len(matches)))
@@ -312,7 +1411,7 @@ func formatAllSecretsForAnalysis(matches []*scanner.Match) string {
 			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, match.Pattern.Name))
 			sb.WriteString(fmt.Sprintf("   File: %s:%d\n", match.FilePath, match.LineNumber))
 			sb.WriteString(fmt.Sprintf("   Type: %s\n", match.Pattern.Description))
-			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(match.LineContent)))
+			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(aiContext(match))))
 		}
 	}
 
@@ -322,7 +1421,7 @@ func formatAllSecretsForAnalysis(matches []*scanner.Match) string {
 			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, match.Pattern.Name))
 			sb.WriteString(fmt.Sprintf("   File: %s:%d\n", match.FilePath, match.LineNumber))
 			sb.WriteString(fmt.Sprintf("   Type: %s\n", match.Pattern.Description))
-			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(match.LineContent)))
+			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(aiContext(match))))
 		}
 	}
 
@@ -332,42 +1431,45 @@ func formatAllSecretsForAnalysis(matches []*scanner.Match) string {
 			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, match.Pattern.Name))
 			sb.WriteString(fmt.Sprintf("   File: %s:%d\n", match.FilePath, match.LineNumber))
 			sb.WriteString(fmt.Sprintf("   Type: %s\n", match.Pattern.Description))
-			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(match.LineContent)))
+			sb.WriteString(fmt.Sprintf("   Context: %s\n\n", strings.TrimSpace(aiContext(match))))
 		}
 	}
 
 	return sb.String()
 }
 
-func analyzeLogWithAI(logPath string) error {
-	fmt.Fprintf(os.Stderr, "🤖 Analyzing log file with local LLM...\n")
-	fmt.Fprintf(os.Stderr, "📄 Log file: %s\n\n", logPath)
-
-	analyzer := llm.NewAnalyzer()
-	analyzer.SetModel(defaultModel)
-	analyzer.SetOllamaURL(ollamaURL)
-	analyzer.SetChunkLines(chunkLines)
+// readLogChunks splits a log into chunks of chunkLines lines. With a positive limit, reading stops at the
+// first line that would take the content past limit bytes; kept is how much was chunked and dropped how
+// much of the file was left unread.
+func readLogChunks(file *os.File, chunkLines int, limit int64) (chunks []string, kept, dropped int64, err error) {
+	var currentChunk strings.Builder
+	var lineCount int
 
-	fmt.Fprintf(os.Stderr, "⏳ Checking Ollama connection...\n")
-	if err := analyzer.HealthCheck(); err != nil {
-		return fmt.Errorf("❌ %w\nMake sure Ollama is running: ollama serve", err)
+	// One byte past the limit is enough to tell the log goes on, even within a single overlong line
+	var r io.Reader = file
+	if limit > 0 {
+		r = io.LimitReader(file, limit+1)
 	}
 
-	file, err := os.Open(logPath)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
+	truncated := false
+	reader := bufio.NewReader(r)
+	for {
+		raw, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, 0, 0, readErr
+		}
+		if raw == "" {
+			break
+		}
 
-	// Split file into chunks
-	chunks := make([]string, 0)
-	var currentChunk strings.Builder
-	var lineCount int
+		size := int64(len(raw))
+		if limit > 0 && kept+size > limit {
+			truncated = true
+			break
+		}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		currentChunk.WriteString(line)
+		kept += size
+		currentChunk.WriteString(strings.TrimRight(raw, "\r\n"))
 		currentChunk.WriteString("\n")
 		lineCount++
 
@@ -376,50 +1478,185 @@ func analyzeLogWithAI(logPath string) error {
 			currentChunk.Reset()
 			lineCount = 0
 		}
+		if readErr == io.EOF {
+			break
+		}
 	}
 
 	if currentChunk.Len() > 0 {
 		chunks = append(chunks, currentChunk.String())
 	}
 
-	if err := scanner.Err(); err != nil {
+	// The rest of the file is not read, so its size says how much was dropped
+	if truncated {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		dropped = max(info.Size()-kept, 0)
+	}
+	return chunks, kept, dropped, nil
+}
+
+// rollupLogAnalysis sends the summaries of the chunks that were analyzed to query with LogRollupPrompt,
+// for one consolidated summary of the whole log
+func rollupLogAnalysis(chunkResults []*llm.ChunkResult, query func(prompt string) (*llm.AnalysisResult, error)) (*llm.AnalysisResult, error) {
+	var summaries strings.Builder
+	for _, chunkResult := range chunkResults {
+		if chunkResult.Err != nil || chunkResult.Result == nil {
+			continue
+		}
+		fmt.Fprintf(&summaries, "=== Chunk %d Summary ===\n%s\n\n", chunkResult.Index+1, strings.TrimSpace(chunkResult.Result.Findings))
+	}
+	if summaries.Len() == 0 {
+		return nil, fmt.Errorf("no chunk summaries to consolidate")
+	}
+	return query(llm.LogRollupPrompt(summaries.String()))
+}
+
+func analyzeLogWithAI(logPath string) error {
+	limit, err := utils.ParseBytes(maxLogSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-log-size value: %w", err)
+	}
+
+	console.Infof("🤖 Analyzing log file with local LLM...\n")
+	console.Infof("📄 Log file: %s\n\n", logPath)
+
+	analyzer, err := newAnalyzer()
+	if err != nil {
+		return err
+	}
+
+	console.Infof("⏳ Checking Ollama connection...\n")
+	if err := analyzer.HealthCheck(); err != nil {
+		return fmt.Errorf("❌ %w\nMake sure Ollama is running: ollama serve", err)
+	}
+
+	if err := ensureModel(analyzer); err != nil {
+		return err
+	}
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	chunks, kept, dropped, err := readLogChunks(file, chunkLines, limit)
+	if err != nil {
 		return fmt.Errorf("error reading log file: %w", err)
 	}
+	if dropped > 0 {
+		console.Warnf("⚠️  Log is larger than --max-log-size %s: analyzing the first %s and dropping the last %s\n",
+			utils.FormatBytes(limit), utils.FormatBytes(kept), utils.FormatBytes(dropped))
+	}
 
 	if len(chunks) == 0 {
 		return fmt.Errorf("log file is empty")
 	}
 
-	fmt.Fprintf(os.Stderr, "⏳ Querying %s model...\n\n", analyzer.Model)
+	console.Infof("⏳ Querying %s model...\n\n", analyzer.Model)
 
-	var results strings.Builder
-	for i, chunk := range chunks {
-		fmt.Fprintf(os.Stderr, "📊 Processing chunk %d/%d...\n", i+1, len(chunks))
+	// Streamed output from parallel chunks would interleave, so stream one chunk at a time
+	workers := concurrency
+	if aiStream {
+		workers = 1
+	}
 
-		prompt := llm.LogAnalysisPrompt(chunk)
-		result, err := analyzer.Query(prompt)
+	// Ctrl-C stops the chunks still in flight and reports the ones that finished
+	ctx, stop := interruptContext()
+	defer stop()
+
+	console.Infof("📊 Processing %d chunks (%d at a time)...\n", len(chunks), workers)
+	chunkResults := llm.AnalyzeChunks(chunks, workers, func(chunk string) (*llm.AnalysisResult, error) {
+		prompt, err := analyzer.Prompt(llm.PromptData{Content: chunk}, llm.LogAnalysisPrompt(chunk))
 		if err != nil {
-			return fmt.Errorf("❌ Analysis failed: %w", err)
+			return nil, err
+		}
+		return queryAI(ctx, analyzer, prompt)
+	})
+
+	var results strings.Builder
+	var chunkAnalyses []*llm.AnalysisResult
+	failed, interrupted := 0, 0
+	for _, chunkResult := range chunkResults {
+		if ctx.Err() != nil && errors.Is(chunkResult.Err, context.Canceled) {
+			interrupted++
+			continue
+		}
+
+		if chunkResult.Err != nil {
+			failed++
+			console.Warnf("⚠️  Chunk %d/%d failed: %v\n", chunkResult.Index+1, len(chunks), chunkResult.Err)
+			results.WriteString(fmt.Sprintf("=== Chunk %d Failed ===\n%v\n\n", chunkResult.Index+1, chunkResult.Err))
+			continue
 		}
 
-		results.WriteString(fmt.Sprintf("=== Chunk %d Summary ===\n", i+1))
-		results.WriteString(result.Findings)
+		chunkAnalyses = append(chunkAnalyses, chunkResult.Result)
+		results.WriteString(fmt.Sprintf("=== Chunk %d Summary ===\n", chunkResult.Index+1))
+		results.WriteString(chunkResult.Result.Findings)
 		results.WriteString("\n\n")
 	}
 
+	if interrupted > 0 {
+		console.Warnf("\n⚠️  Interrupted: %d of %d chunks were not analyzed\n", interrupted, len(chunks))
+		if interrupted == len(chunks) {
+			return fmt.Errorf("❌ Analysis interrupted before any chunk completed")
+		}
+		results.WriteString(fmt.Sprintf("=== Interrupted: %d of %d chunks not analyzed ===\n", interrupted, len(chunks)))
+	} else if failed == len(chunks) {
+		return fmt.Errorf("❌ Analysis failed for all %d chunks", failed)
+	}
+
+	content := results.String()
+	if (rollup || rollupOnly) && ctx.Err() == nil {
+		console.Infof("🧾 Consolidating %d chunk summaries...\n", len(chunkAnalyses))
+		rolled, err := rollupLogAnalysis(chunkResults, func(prompt string) (*llm.AnalysisResult, error) {
+			return queryAI(ctx, analyzer, prompt)
+		})
+		switch {
+		case err != nil:
+			// The chunk summaries are still worth reporting on their own
+			console.Warnf("⚠️  Rollup failed, reporting the chunk summaries: %v\n", err)
+		case rollupOnly:
+			content = "=== Rollup Summary ===\n" + rolled.Findings + "\n"
+			chunkAnalyses = append(chunkAnalyses, rolled)
+		default:
+			content += "=== Rollup Summary ===\n" + rolled.Findings + "\n"
+			chunkAnalyses = append(chunkAnalyses, rolled)
+		}
+	}
+
 	analysisReport := &report.AnalysisReport{
-		Title:    "Log Analysis Results",
-		Model:    analyzer.Model,
-		Content:  results.String(),
-		Duration: "n/a",
+		Title:     "Log Analysis Results",
+		Model:     analyzer.Model,
+		Content:   content,
+		Duration:  "n/a",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
+	analysisReport.PromptTokens, analysisReport.EvalTokens, analysisReport.TokensPerSec = llm.TotalUsage(chunkAnalyses...)
 
-	rpt := report.NewReport(os.Stdout, "text")
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
+	}
+
+	rpt := report.NewReport(out, format)
 	if err := rpt.GenerateAnalysis(analysisReport); err != nil {
+		closeOutput(out)
 		return fmt.Errorf("failed to generate analysis report: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "✅ Analysis complete\n")
+	if err := closeOutput(out); err != nil {
+		return err
+	}
+
+	if interrupted > 0 {
+		return fmt.Errorf("❌ Analysis interrupted, partial results written for %d of %d chunks", len(chunks)-interrupted, len(chunks))
+	}
+
+	console.Infof("✅ Analysis complete\n")
 
 	return nil
 }
@@ -0,0 +1,281 @@
+// Package goscout scans files for secrets from other Go programs. It wires the scanner, pattern
+// selection, baseline and severity filters, and optional AI analysis behind a single Scan call,
+// the same way the goscout command does.
+package goscout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deadrootsec/goscout/pkg/llm"
+	"github.com/deadrootsec/goscout/pkg/patterns"
+	"github.com/deadrootsec/goscout/pkg/report"
+	"github.com/deadrootsec/goscout/pkg/scanner"
+	"github.com/deadrootsec/goscout/pkg/utils"
+)
+
+// Options configures a scan. The zero value scans the current directory with the scanner's defaults.
+type Options struct {
+	// Paths are the files and directories to scan, "." when empty
+	Paths []string
+
+	// Context cancels the scan; matches found before cancellation are still returned
+	Context context.Context
+
+	ExcludeDirs       []string
+	ExcludeFiles      []string
+	ExcludeGlobs      []string // gitignore-style globs relative to each scan root
+	IncludeExtensions []string // scan only files with these extensions, all files when empty
+	IgnoreFile        string   // rules to use instead of each root's .goscoutignore
+
+	MaxFileSize       int64 // in bytes, the scanner default of 10MB when zero
+	MaxLineLength     int   // longest line matched in one piece, scanner.DefaultMaxLineLength when zero
+	Concurrency       int   // files scanned in parallel, one per CPU when zero
+	MaxMatchesPerFile int   // stop scanning a file after this many matches, no limit when zero
+	ContextLines      int   // lines kept before and after each match
+
+	// FileTimeout abandons a file that takes longer to scan, scanner.DefaultFileTimeout when zero
+	// and no limit when negative
+	FileTimeout time.Duration
+
+	RespectGitignore bool
+	FollowSymlinks   bool
+	ScanArchives     bool
+
+	// SkipGenerated skips files with a generated-code marker in their first lines. GeneratedMarkers are
+	// the markers as regular expressions, scanner.DefaultGeneratedMarkers when empty.
+	SkipGenerated    bool
+	GeneratedMarkers []string
+	FailFast         bool
+
+	// CollectStats records the scan duration, bytes read and time spent matching in ScanResult.Stats
+	CollectStats bool
+
+	EntropyThreshold float64  // bits per character for high entropy strings, disabled when zero
+	KeywordProximity int      // characters searched for a keyword around generic matches, disabled when zero
+	Keywords         []string // keywords for KeywordProximity, scanner.DefaultProximityKeywords when empty
+	Verify           bool
+	Dedup            bool
+	MultilineAssign  bool
+
+	// OnlyPatterns and DisablePatterns select built-in patterns by name, as patterns.Select does
+	OnlyPatterns    []string
+	DisablePatterns []string
+
+	// PII adds the personal data patterns, such as card numbers and email addresses, to the scan
+	PII bool
+
+	// Network adds the network indicator patterns, such as private IP addresses, to the scan
+	Network bool
+
+	// Categories restricts the scan to patterns in these categories, before OnlyPatterns and
+	// DisablePatterns apply. Asking for patterns.CategoryPII implies PII, and patterns.CategoryNetwork Network.
+	Categories []string
+
+	// CustomPatterns are added to the built-in ones before any selection, replacing built-in patterns
+	// of the same name, as patterns.Merge does. Only those with Enabled set are scanned with.
+	CustomPatterns []patterns.Pattern
+
+	// BaselinePath drops findings recorded in a baseline file
+	BaselinePath string
+
+	// Severity keeps findings of exactly this severity, MinSeverity those of at least this severity
+	Severity    string
+	MinSeverity string
+
+	// Analyzer, when set, analyzes each finding with the LLM. MaxAnalyses caps how many are analyzed.
+	Analyzer       *llm.Analyzer
+	RedactBeforeAI bool
+	MaxAnalyses    int
+}
+
+// ScanResult is the outcome of Scan
+type ScanResult struct {
+	Matches      []*scanner.Match
+	FilesScanned int
+	FilesSkipped int
+	Errors       []error            // files that could not be scanned
+	CappedFiles  []string           // files that reached MaxMatchesPerFile
+	Stats        *scanner.ScanStats // set with Options.CollectStats
+
+	// Analyses, AnalysisErrors and AnalysesSkipped are set when Options.Analyzer is
+	Analyses        []*scanner.AnalyzedMatch
+	AnalysisErrors  []error
+	AnalysesSkipped int
+}
+
+// Scan scans the paths in opts and returns the findings left after the baseline and severity filters,
+// analyzed by the LLM when an analyzer is set
+func Scan(opts Options) (*ScanResult, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	sc, err := NewScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanned, err := sc.ScanPathsContext(ctx, paths)
+	if scanned == nil {
+		return nil, err
+	}
+
+	result := &ScanResult{
+		Matches:      scanned.Matches,
+		FilesScanned: scanned.FilesScanned,
+		FilesSkipped: scanned.FilesSkipped,
+		Errors:       scanned.Errors,
+		CappedFiles:  scanned.CappedFiles,
+		Stats:        scanned.Stats,
+	}
+	if err != nil {
+		// Canceled: the partial findings are returned unfiltered along with the error
+		return result, err
+	}
+
+	if opts.BaselinePath != "" {
+		baseline, err := report.LoadBaseline(opts.BaselinePath)
+		if err != nil {
+			return nil, err
+		}
+		result.Matches = baseline.Filter(result.Matches)
+	}
+
+	result.Matches = FilterSeverity(result.Matches, opts.Severity, opts.MinSeverity)
+
+	if opts.Analyzer != nil {
+		sc.SetAnalyzer(opts.Analyzer)
+		sc.SetRedactBeforeAI(opts.RedactBeforeAI)
+		sc.SetMaxAnalyses(opts.MaxAnalyses)
+
+		analyzed := sc.AnalyzeMatches(result.Matches)
+		result.Analyses = analyzed.AnalyzedMatches
+		result.AnalysisErrors = analyzed.AnalysisErrors
+		result.AnalysesSkipped = analyzed.AnalysesSkipped
+	}
+
+	return result, nil
+}
+
+// NewScanner returns a scanner configured with the scanning options in opts
+func NewScanner(opts Options) (*scanner.Scanner, error) {
+	sc := scanner.NewScanner()
+	if opts.MaxFileSize > 0 {
+		sc.SetMaxFileSize(opts.MaxFileSize)
+	}
+	sc.SetMaxLineLength(opts.MaxLineLength)
+	if opts.FileTimeout != 0 {
+		sc.SetFileTimeout(max(opts.FileTimeout, 0))
+	}
+	sc.SetFailFast(opts.FailFast)
+	sc.SetCollectStats(opts.CollectStats)
+	sc.SetConcurrency(opts.Concurrency)
+	sc.SetRespectGitignore(opts.RespectGitignore)
+	sc.SetEntropyThreshold(opts.EntropyThreshold)
+	sc.SetContextLines(opts.ContextLines)
+	sc.SetIncludeExtensions(opts.IncludeExtensions)
+	sc.SetScanArchives(opts.ScanArchives)
+	sc.SetVerify(opts.Verify)
+	sc.SetDedup(opts.Dedup)
+	sc.SetMultilineAssign(opts.MultilineAssign)
+	sc.SetMaxMatchesPerFile(opts.MaxMatchesPerFile)
+	sc.SetFollowSymlinks(opts.FollowSymlinks)
+
+	keywords := opts.Keywords
+	if len(keywords) == 0 {
+		keywords = scanner.DefaultProximityKeywords
+	}
+	sc.SetKeywordProximity(opts.KeywordProximity, keywords)
+
+	if opts.PII || opts.Network || len(opts.Categories) > 0 || len(opts.OnlyPatterns) > 0 || len(opts.DisablePatterns) > 0 || len(opts.CustomPatterns) > 0 {
+		available := Patterns(opts.PII || utils.ContainsString(opts.Categories, patterns.CategoryPII),
+			opts.Network || utils.ContainsString(opts.Categories, patterns.CategoryNetwork))
+		available = patterns.Merge(available, opts.CustomPatterns)
+		if len(opts.Categories) > 0 {
+			var err error
+			if available, err = patterns.FilterCategories(available, opts.Categories); err != nil {
+				return nil, err
+			}
+		}
+
+		selected, err := patterns.Select(available, opts.OnlyPatterns, opts.DisablePatterns)
+		if err != nil {
+			return nil, err
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no patterns left to scan with after applying the pattern selection")
+		}
+		sc.SetPatterns(selected)
+	}
+
+	for _, dir := range opts.ExcludeDirs {
+		sc.AddExcludeDir(dir)
+	}
+
+	for _, file := range opts.ExcludeFiles {
+		sc.AddExcludeFile(file)
+	}
+
+	for _, glob := range opts.ExcludeGlobs {
+		if err := sc.AddExcludePattern(glob); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.IgnoreFile != "" {
+		if err := sc.SetIgnoreFile(opts.IgnoreFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.SkipGenerated {
+		if err := sc.SetSkipGenerated(opts.GeneratedMarkers); err != nil {
+			return nil, err
+		}
+	}
+
+	return sc, nil
+}
+
+// Patterns returns the enabled built-in secret patterns, followed by the enabled personal data
+// patterns when pii is set and the enabled network patterns when network is set
+func Patterns(pii, network bool) []patterns.Pattern {
+	all := patterns.GetEnabledPatterns()
+	if pii {
+		all = append(all, patterns.GetEnabledPIIPatterns()...)
+	}
+	if network {
+		all = append(all, patterns.GetEnabledNetworkPatterns()...)
+	}
+	return all
+}
+
+// FilterSeverity keeps matches of exactly the exact severity, when set, and of at least the
+// min severity, when set
+func FilterSeverity(matches []*scanner.Match, exact, min string) []*scanner.Match {
+	if exact == "" && min == "" {
+		return matches
+	}
+
+	threshold := patterns.SeverityRank(min)
+	filtered := make([]*scanner.Match, 0)
+	for _, match := range matches {
+		if exact != "" && !strings.EqualFold(match.Pattern.Severity, exact) {
+			continue
+		}
+		if patterns.SeverityRank(match.Pattern.Severity) < threshold {
+			continue
+		}
+		filtered = append(filtered, match)
+	}
+	return filtered
+}
@@ -2,33 +2,63 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	OllamaDefaultURL  = "http://localhost:11434"
-	DefaultModel      = "qwen3:1.7b"
-	DefaultChunkLines = 2000
-	RequestTimeout    = 30 * time.Minute
+	OllamaDefaultURL   = "http://localhost:11434"
+	DefaultModel       = "qwen3:1.7b"
+	DefaultChunkLines  = 2000
+	RequestTimeout     = 30 * time.Minute
+	HealthCheckTimeout = 5 * time.Second
+	DefaultMaxRetries  = 3
+	retryBaseDelay     = 500 * time.Millisecond
+	retryMaxDelay      = 10 * time.Second
 )
 
+// ErrModelNotFound is returned when the configured model has not been pulled into Ollama
+var ErrModelNotFound = errors.New("not found")
+
 // Analyzer handles communication with Ollama for analysis
 type Analyzer struct {
 	OllamaURL  string
 	Model      string
 	ChunkLines int
+	MaxRetries int
 	Client     *http.Client
+	APIType    string
+
+	// CacheDir holds responses keyed by model and prompt; empty disables caching
+	CacheDir string
+
+	// Template replaces the built-in prompts when set
+	Template *PromptTemplate
+
+	// Options holds Ollama generation options such as temperature and num_ctx; nil leaves the model defaults
+	Options map[string]interface{}
+
+	// backend speaks the wire format selected by APIType
+	backend backend
+
+	// retryDelay is the first backoff delay, doubled on every further attempt
+	retryDelay time.Duration
 }
 
 // OllamaRequest represents a request to Ollama API
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string                 `json:"model"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
 // OllamaResponse represents a response from Ollama API
@@ -36,6 +66,46 @@ type OllamaResponse struct {
 	Model    string `json:"model"`
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
+
+	// Statistics reported with the final response; durations are in nanoseconds
+	PromptEvalCount int   `json:"prompt_eval_count,omitempty"`
+	EvalCount       int   `json:"eval_count,omitempty"`
+	EvalDuration    int64 `json:"eval_duration,omitempty"`
+	TotalDuration   int64 `json:"total_duration,omitempty"`
+}
+
+// tokensPerSec returns the generation speed reported by Ollama, or 0 when it is unknown
+func (r *OllamaResponse) tokensPerSec() float64 {
+	if r.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(r.EvalCount) / time.Duration(r.EvalDuration).Seconds()
+}
+
+// OllamaPullRequest represents a request to pull a model
+type OllamaPullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+// OllamaPullStatus represents a progress update while pulling a model
+type OllamaPullStatus struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// OllamaTagsResponse represents the list of locally available models
+type OllamaTagsResponse struct {
+	Models []OllamaModel `json:"models"`
+}
+
+// OllamaModel describes a model pulled into Ollama
+type OllamaModel struct {
+	Name string `json:"name"`
 }
 
 // AnalysisResult contains the analysis findings
@@ -43,6 +113,39 @@ type AnalysisResult struct {
 	Findings string
 	Model    string
 	Duration time.Duration
+
+	// Token usage as reported by Ollama; zero when the server did not report it
+	PromptTokens int
+	EvalTokens   int
+	TokensPerSec float64
+}
+
+// TotalUsage adds up the token usage of several results. The combined speed is the total number
+// of generated tokens over the total generation time.
+func TotalUsage(results ...*AnalysisResult) (promptTokens, evalTokens int, tokensPerSec float64) {
+	var seconds float64
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		promptTokens += result.PromptTokens
+		evalTokens += result.EvalTokens
+		if result.TokensPerSec > 0 {
+			seconds += float64(result.EvalTokens) / result.TokensPerSec
+		}
+	}
+
+	if seconds > 0 {
+		tokensPerSec = float64(evalTokens) / seconds
+	}
+	return promptTokens, evalTokens, tokensPerSec
+}
+
+// ChunkResult holds the outcome of analyzing one chunk
+type ChunkResult struct {
+	Index  int
+	Result *AnalysisResult
+	Err    error
 }
 
 // SecretAnalysisResult contains detailed analysis of a secret finding
@@ -61,9 +164,13 @@ func NewAnalyzer() *Analyzer {
 		OllamaURL:  OllamaDefaultURL,
 		Model:      DefaultModel,
 		ChunkLines: DefaultChunkLines,
+		MaxRetries: DefaultMaxRetries,
 		Client: &http.Client{
 			Timeout: RequestTimeout,
 		},
+		APIType:    APITypeOllama,
+		backend:    ollamaBackend{},
+		retryDelay: retryBaseDelay,
 	}
 }
 
@@ -79,14 +186,73 @@ func (a *Analyzer) SetChunkLines(lines int) {
 	}
 }
 
+// SetMaxRetries sets how many times a transient failure is retried
+func (a *Analyzer) SetMaxRetries(n int) {
+	if n >= 0 {
+		a.MaxRetries = n
+	}
+}
+
 // SetOllamaURL sets the Ollama server URL
 func (a *Analyzer) SetOllamaURL(url string) {
 	a.OllamaURL = url
 }
 
+// SetAPIType selects the server API: APITypeOllama (the default) or APITypeOpenAI
+func (a *Analyzer) SetAPIType(apiType string) error {
+	b, err := newBackend(apiType)
+	if err != nil {
+		return err
+	}
+
+	a.APIType = apiType
+	a.backend = b
+	return nil
+}
+
+// SetOption sets an Ollama generation option sent with every request
+func (a *Analyzer) SetOption(name string, value interface{}) {
+	if a.Options == nil {
+		a.Options = make(map[string]interface{})
+	}
+	a.Options[name] = value
+}
+
+// SetTemperature sets the sampling temperature; lower values give more deterministic output
+func (a *Analyzer) SetTemperature(temperature float64) {
+	if temperature >= 0 {
+		a.SetOption("temperature", temperature)
+	}
+}
+
+// SetTopP sets nucleus sampling, keeping the smallest set of tokens whose probability adds up to p
+func (a *Analyzer) SetTopP(p float64) {
+	if p > 0 && p <= 1 {
+		a.SetOption("top_p", p)
+	}
+}
+
+// SetContextWindow sets the context window size in tokens
+func (a *Analyzer) SetContextWindow(tokens int) {
+	if tokens > 0 {
+		a.SetOption("num_ctx", tokens)
+	}
+}
+
+// SetTimeout sets how long a single request to Ollama may take, including reading the response
+func (a *Analyzer) SetTimeout(d time.Duration) {
+	if d > 0 {
+		a.Client.Timeout = d
+	}
+}
+
 // HealthCheck verifies that Ollama is running
 func (a *Analyzer) HealthCheck() error {
-	req, err := http.NewRequest("GET", a.OllamaURL+"/api/tags", nil)
+	// Connection checks fail fast no matter how long analysis requests may take
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", a.OllamaURL+a.backend.modelsPath(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
@@ -104,30 +270,199 @@ func (a *Analyzer) HealthCheck() error {
 	return nil
 }
 
-// Query sends a prompt to Ollama and gets the response
-func (a *Analyzer) Query(prompt string) (*AnalysisResult, error) {
-	reqBody := OllamaRequest{
-		Model:  a.Model,
-		Prompt: prompt,
-		Stream: false,
+// ListModels returns the names of the models available on the Ollama server
+func (a *Analyzer) ListModels() ([]string, error) {
+	req, err := http.NewRequest("GET", a.OllamaURL+a.backend.modelsPath(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list models request: %w", err)
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama server not responding at %s: %w", a.OllamaURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama server returned status code %d", resp.StatusCode)
+	}
+
+	return a.backend.decodeModels(resp.Body)
+}
+
+// VerifyModel checks that the configured model has been pulled into Ollama.
+// A model name without a tag matches the ":latest" tag, as in Ollama itself.
+func (a *Analyzer) VerifyModel() error {
+	models, err := a.ListModels()
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		if model == a.Model || model == a.Model+":latest" {
+			return nil
+		}
+	}
+
+	if len(models) == 0 {
+		return fmt.Errorf("model '%s' %w; no models are available, pull one with: ollama pull %s", a.Model, ErrModelNotFound, a.Model)
+	}
+	return fmt.Errorf("model '%s' %w; available: %s", a.Model, ErrModelNotFound, strings.Join(models, ", "))
+}
+
+// PullModel downloads a model into Ollama, writing progress to progress as it streams in
+func (a *Analyzer) PullModel(name string, progress io.Writer) error {
+	if _, ok := a.backend.(ollamaBackend); !ok {
+		return fmt.Errorf("pulling models is only supported by the %s API", APITypeOllama)
+	}
+
+	jsonBody, err := json.Marshal(OllamaPullRequest{Model: name, Stream: true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pull request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", a.OllamaURL+"/api/pull", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create pull request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to pull model '%s': %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to pull model '%s': status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	inProgress := false
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var status OllamaPullStatus
+		if err := decoder.Decode(&status); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("failed to pull model '%s': stream ended before completion", name)
+			}
+			return fmt.Errorf("failed to parse pull progress: %w", err)
+		}
+
+		if status.Error != "" {
+			if inProgress {
+				fmt.Fprintln(progress)
+			}
+			return fmt.Errorf("failed to pull model '%s': %s", name, status.Error)
+		}
+
+		// Download updates carry sizes and are redrawn in place
+		if status.Total > 0 {
+			fmt.Fprintf(progress, "\r%s %d%%", status.Status, status.Completed*100/status.Total)
+			inProgress = true
+			continue
+		}
+
+		if inProgress {
+			fmt.Fprintln(progress)
+			inProgress = false
+		}
+		fmt.Fprintln(progress, status.Status)
+
+		if status.Status == "success" {
+			return nil
+		}
+	}
+}
+
+// newGenerateRequest builds a generation request in the format of the configured API
+func (a *Analyzer) newGenerateRequest(ctx context.Context, prompt string, stream bool) (*http.Request, error) {
+	jsonBody, err := a.backend.encodeRequest(a, prompt, stream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", a.OllamaURL+"/api/generate", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", a.OllamaURL+a.backend.generatePath(), bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// do sends the request built by newReq, retrying connection failures and 5xx responses with
+// exponential backoff and jitter. Client errors (4xx) are returned as is. Retrying stops early
+// when the next attempt would start past the client timeout, which serves as the overall deadline,
+// and as soon as ctx is done.
+func (a *Analyzer) do(ctx context.Context, newReq func() (*http.Request, error)) (*http.Response, error) {
+	deadline := time.Now().Add(a.Client.Timeout)
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		resp, err := a.Client.Do(req)
+		switch {
+		case err != nil:
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to query ollama: %w", err)
+			}
+			lastErr = fmt.Errorf("failed to query ollama: %w", err)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+		default:
+			return resp, nil
+		}
+
+		if attempt >= a.MaxRetries {
+			return nil, lastErr
+		}
+
+		delay := a.backoff(attempt)
+		if a.Client.Timeout > 0 && time.Now().Add(delay).After(deadline) {
+			return nil, lastErr
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to query ollama: %w", ctx.Err())
+		}
+	}
+}
+
+// backoff returns the delay before the given retry attempt, with up to 50% random jitter
+func (a *Analyzer) backoff(attempt int) time.Duration {
+	delay := a.retryDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// Query sends a prompt to Ollama and gets the response, answering from the cache when it can
+func (a *Analyzer) Query(prompt string) (*AnalysisResult, error) {
+	return a.QueryContext(context.Background(), prompt)
+}
+
+// QueryContext is like Query but gives up as soon as ctx is canceled, returning an error that wraps ctx.Err()
+func (a *Analyzer) QueryContext(ctx context.Context, prompt string) (*AnalysisResult, error) {
+	if result := a.cached(prompt); result != nil {
+		return result, nil
+	}
 
 	startTime := time.Now()
-	resp, err := a.Client.Do(req)
+	resp, err := a.do(ctx, func() (*http.Request, error) {
+		return a.newGenerateRequest(ctx, prompt, false)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query ollama: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -136,24 +471,55 @@ func (a *Analyzer) Query(prompt string) (*AnalysisResult, error) {
 		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	result, err := a.backend.decodeResponse(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(respBody, &ollamaResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	result.Model = a.Model
+	result.Duration = time.Since(startTime)
+	a.store(prompt, result)
+	return result, nil
+}
+
+// QueryStream sends a prompt to Ollama with streaming enabled, writing tokens to w as they arrive.
+// The full response is still accumulated into the returned findings. A cached response is written in one go.
+func (a *Analyzer) QueryStream(prompt string, w io.Writer) (*AnalysisResult, error) {
+	return a.QueryStreamContext(context.Background(), prompt, w)
+}
+
+// QueryStreamContext is like QueryStream but stops reading the stream as soon as ctx is canceled
+func (a *Analyzer) QueryStreamContext(ctx context.Context, prompt string, w io.Writer) (*AnalysisResult, error) {
+	if result := a.cached(prompt); result != nil {
+		if _, err := io.WriteString(w, result.Findings); err != nil {
+			return nil, fmt.Errorf("failed to write stream: %w", err)
+		}
+		return result, nil
 	}
 
-	duration := time.Since(startTime)
+	startTime := time.Now()
+	resp, err := a.do(ctx, func() (*http.Request, error) {
+		return a.newGenerateRequest(ctx, prompt, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	return &AnalysisResult{
-		Findings: ollamaResp.Response,
-		Model:    a.Model,
-		Duration: duration,
-	}, nil
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	result, err := a.backend.decodeStream(resp.Body, w)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Model = a.Model
+	result.Duration = time.Since(startTime)
+	a.store(prompt, result)
+	return result, nil
 }
 
 // AnalyzeSecrets sends detected secrets to the analyzer for detailed analysis
@@ -179,6 +545,33 @@ func (a *Analyzer) AnalyzeLogs(logContent string) (*AnalysisResult, error) {
 	return a.Query(prompt)
 }
 
+// AnalyzeChunks runs analyze over every chunk with at most concurrency calls in flight.
+// Results are returned in chunk order and a failing chunk does not stop the others.
+func AnalyzeChunks(chunks []string, concurrency int, analyze func(chunk string) (*AnalysisResult, error)) []*ChunkResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*ChunkResult, len(chunks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := analyze(chunk)
+			results[i] = &ChunkResult{Index: i, Result: result, Err: err}
+		}(i, chunk)
+	}
+
+	wg.Wait()
+	return results
+}
+
 // AnalyzeCode sends code content to the analyzer for security analysis
 func (a *Analyzer) AnalyzeCode(codeContent string) (*AnalysisResult, error) {
 	prompt := CodeSecurityPrompt(codeContent)
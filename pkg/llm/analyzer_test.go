@@ -1,6 +1,14 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -164,3 +172,512 @@ func TestAnalyzerClientTimeout(t *testing.T) {
 		t.Errorf("expected timeout %v, got %v", RequestTimeout, analyzer.Client.Timeout)
 	}
 }
+
+func TestQueryStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("expected stream to be enabled in request")
+		}
+
+		flusher := w.(http.Flusher)
+		for _, token := range []string{"No ", "errors ", "found."} {
+			// Split each object across two writes to exercise partial-line buffering
+			line := fmt.Sprintf(`{"model":"test","response":%q,"done":false}`+"\n", token)
+			fmt.Fprint(w, line[:10])
+			flusher.Flush()
+			fmt.Fprint(w, line[10:])
+			flusher.Flush()
+		}
+		fmt.Fprintln(w, `{"model":"test","response":"","done":true}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	var live strings.Builder
+	result, err := analyzer.QueryStream("prompt", &live)
+	if err != nil {
+		t.Fatalf("QueryStream() returned error: %v", err)
+	}
+
+	if result.Findings != "No errors found." {
+		t.Errorf("expected assembled findings %q, got %q", "No errors found.", result.Findings)
+	}
+
+	if live.String() != result.Findings {
+		t.Errorf("expected tokens written as they arrived, got %q", live.String())
+	}
+}
+
+func TestQueryStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"model":"test","response":"partial","done":false}`)
+		fmt.Fprintln(w, `{"error":"model crashed"}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	var live strings.Builder
+	if _, err := analyzer.QueryStream("prompt", &live); err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected stream error to be surfaced, got %v", err)
+	}
+}
+
+func TestQueryRetriesTransientFailures(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			http.Error(w, "model is loading", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, `{"model":"test","response":"all good","done":true}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.retryDelay = time.Millisecond
+
+	result, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if result.Findings != "all good" {
+		t.Errorf("expected findings %q, got %q", "all good", result.Findings)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestQueryDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.retryDelay = time.Millisecond
+
+	if _, err := analyzer.Query("prompt"); err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected a single attempt for a client error, got %d", calls)
+	}
+}
+
+func TestQueryGivesUpAfterMaxRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetMaxRetries(2)
+	analyzer.retryDelay = time.Millisecond
+
+	if _, err := analyzer.Query("prompt"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected the last 503 error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"models":[{"name":"qwen3:1.7b","size":1},{"name":"llama3.2:3b","size":2}]}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	models, err := analyzer.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels() returned error: %v", err)
+	}
+
+	if strings.Join(models, ",") != "qwen3:1.7b,llama3.2:3b" {
+		t.Errorf("unexpected models %v", models)
+	}
+}
+
+func TestListModelsEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"models":[]}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	models, err := analyzer.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels() returned error: %v", err)
+	}
+
+	if len(models) != 0 {
+		t.Errorf("expected no models, got %v", models)
+	}
+}
+
+func TestListModelsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(url)
+
+	if _, err := analyzer.ListModels(); err == nil || !strings.Contains(err.Error(), "not responding") {
+		t.Errorf("expected an unreachable server error, got %v", err)
+	}
+}
+
+func TestVerifyModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"models":[{"name":"qwen3:1.7b"},{"name":"mistral:latest"}]}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	for _, model := range []string{"qwen3:1.7b", "mistral"} {
+		analyzer.SetModel(model)
+		if err := analyzer.VerifyModel(); err != nil {
+			t.Errorf("VerifyModel() for %q returned error: %v", model, err)
+		}
+	}
+
+	analyzer.SetModel("qwen:1.7bb")
+	err := analyzer.VerifyModel()
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound for a missing model, got %v", err)
+	}
+
+	for _, want := range []string{"qwen:1.7bb", "qwen3:1.7b", "mistral:latest"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+func TestPullModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var req OllamaPullRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "qwen3:1.7b" {
+			t.Errorf("unexpected pull request %+v (%v)", req, err)
+		}
+
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"downloading","digest":"sha256:abc","total":200,"completed":50}`)
+		fmt.Fprintln(w, `{"status":"downloading","digest":"sha256:abc","total":200,"completed":200}`)
+		fmt.Fprintln(w, `{"status":"verifying sha256 digest"}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	var progress strings.Builder
+	if err := analyzer.PullModel("qwen3:1.7b", &progress); err != nil {
+		t.Fatalf("PullModel() returned error: %v", err)
+	}
+
+	for _, want := range []string{"pulling manifest", "25%", "100%", "success"} {
+		if !strings.Contains(progress.String(), want) {
+			t.Errorf("expected progress to contain %q, got %q", want, progress.String())
+		}
+	}
+}
+
+func TestPullModelUnknown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	var progress strings.Builder
+	err := analyzer.PullModel("nope:1b", &progress)
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") || !strings.Contains(err.Error(), "nope:1b") {
+		t.Errorf("expected registry error naming the model, got %v", err)
+	}
+}
+
+func TestAnalyzeChunksPreservesOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Each chunk is a single "chunk-N" line at the end of the prompt
+		chunk := req.Prompt[strings.LastIndex(req.Prompt, "\n")+1:]
+		if chunk == "chunk-2" {
+			http.Error(w, "bad chunk", http.StatusBadRequest)
+			return
+		}
+
+		// Answer earlier chunks last so completion order differs from chunk order
+		var n int
+		fmt.Sscanf(chunk, "chunk-%d", &n)
+		time.Sleep(time.Duration(5-n) * 10 * time.Millisecond)
+
+		json.NewEncoder(w).Encode(OllamaResponse{Response: "summary of " + chunk, Done: true})
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	chunks := []string{"chunk-0", "chunk-1", "chunk-2", "chunk-3", "chunk-4"}
+	results := AnalyzeChunks(chunks, 3, analyzer.AnalyzeLogs)
+
+	if len(results) != len(chunks) {
+		t.Fatalf("expected %d results, got %d", len(chunks), len(results))
+	}
+
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("result %d has index %d", i, result.Index)
+		}
+
+		if i == 2 {
+			if result.Err == nil {
+				t.Error("expected chunk 2 to fail")
+			}
+			continue
+		}
+
+		if result.Err != nil {
+			t.Errorf("chunk %d: unexpected error: %v", i, result.Err)
+			continue
+		}
+
+		if want := "summary of " + chunks[i]; result.Result.Findings != want {
+			t.Errorf("chunk %d: expected %q, got %q", i, want, result.Result.Findings)
+		}
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	analyzer.SetTimeout(90 * time.Second)
+	if analyzer.Client.Timeout != 90*time.Second {
+		t.Errorf("expected timeout 90s, got %v", analyzer.Client.Timeout)
+	}
+
+	analyzer.SetTimeout(0)
+	if analyzer.Client.Timeout != 90*time.Second {
+		t.Errorf("expected a zero timeout to be ignored, got %v", analyzer.Client.Timeout)
+	}
+}
+
+func TestHealthCheckFailsFast(t *testing.T) {
+	// Grab a free port and close it so nothing is listening there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL("http://" + addr)
+
+	start := time.Now()
+	if err := analyzer.HealthCheck(); err == nil {
+		t.Fatal("expected an error for a server that is not listening")
+	}
+
+	if elapsed := time.Since(start); elapsed > HealthCheckTimeout {
+		t.Errorf("health check took %v, expected to fail within %v", elapsed, HealthCheckTimeout)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := analyzer.HealthCheck(); err == nil {
+		t.Fatal("expected a hung server to fail the health check")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("health check took %v, expected the request timeout to apply", elapsed)
+	}
+}
+
+func TestOllamaRequestOptions(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	req, err := analyzer.newGenerateRequest(context.Background(), "prompt", false)
+	if err != nil {
+		t.Fatalf("newGenerateRequest() returned error: %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if _, ok := body["options"]; ok {
+		t.Errorf("expected options to be omitted when unset, got %v", body["options"])
+	}
+
+	analyzer.SetTemperature(0.1)
+	analyzer.SetContextWindow(8192)
+	analyzer.SetTopP(0.9)
+
+	req, err = analyzer.newGenerateRequest(context.Background(), "prompt", false)
+	if err != nil {
+		t.Fatalf("newGenerateRequest() returned error: %v", err)
+	}
+	body = nil
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	options, ok := body["options"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected an options object, got %v", body["options"])
+	}
+
+	if options["temperature"] != 0.1 {
+		t.Errorf("expected temperature 0.1, got %v", options["temperature"])
+	}
+	if options["num_ctx"] != float64(8192) {
+		t.Errorf("expected num_ctx 8192, got %v", options["num_ctx"])
+	}
+	if options["top_p"] != 0.9 {
+		t.Errorf("expected top_p 0.9, got %v", options["top_p"])
+	}
+}
+
+func TestOllamaResponseStats(t *testing.T) {
+	data := `{"model":"qwen3:1.7b","response":"ok","done":true,"prompt_eval_count":120,"eval_count":80,"eval_duration":2000000000,"total_duration":2500000000}`
+
+	var resp OllamaResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if resp.PromptEvalCount != 120 || resp.EvalCount != 80 {
+		t.Errorf("unexpected token counts: prompt %d, eval %d", resp.PromptEvalCount, resp.EvalCount)
+	}
+	if time.Duration(resp.TotalDuration) != 2500*time.Millisecond {
+		t.Errorf("expected total duration 2.5s, got %v", time.Duration(resp.TotalDuration))
+	}
+	if got := resp.tokensPerSec(); got != 40 {
+		t.Errorf("expected 40 tokens/s, got %v", got)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, data)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	result, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if result.PromptTokens != 120 || result.EvalTokens != 80 || result.TokensPerSec != 40 {
+		t.Errorf("unexpected stats on result: %+v", result)
+	}
+}
+
+func TestTotalUsage(t *testing.T) {
+	prompt, eval, speed := TotalUsage(
+		&AnalysisResult{PromptTokens: 100, EvalTokens: 40, TokensPerSec: 20},
+		nil,
+		&AnalysisResult{PromptTokens: 50, EvalTokens: 20, TokensPerSec: 10},
+	)
+
+	if prompt != 150 || eval != 60 {
+		t.Errorf("expected 150 prompt and 60 eval tokens, got %d and %d", prompt, eval)
+	}
+
+	// 60 tokens over 2s + 2s of generation
+	if speed != 15 {
+		t.Errorf("expected 15 tokens/s, got %v", speed)
+	}
+}
+
+func TestQueryContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hold the request open like a model that takes minutes to answer
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := analyzer.QueryContext(ctx, "prompt")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the query to abort promptly, took %v", elapsed)
+	}
+
+	// A context that is already canceled never reaches the server
+	if _, err := analyzer.QueryContext(ctx, "prompt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a context.Canceled error, got %v", err)
+	}
+}
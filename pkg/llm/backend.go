@@ -0,0 +1,269 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// APITypeOllama talks to Ollama's native /api endpoints
+	APITypeOllama = "ollama"
+
+	// APITypeOpenAI talks to an OpenAI-compatible /v1 API such as llama.cpp or vLLM
+	APITypeOpenAI = "openai"
+)
+
+// backend translates between the analyzer and the wire format of one server API
+type backend interface {
+	// generatePath and modelsPath are the endpoints for generation and for listing models
+	generatePath() string
+	modelsPath() string
+
+	// encodeRequest builds the JSON body of a generation request
+	encodeRequest(a *Analyzer, prompt string, stream bool) ([]byte, error)
+
+	// decodeResponse parses a complete, non-streamed generation response
+	decodeResponse(body io.Reader) (*AnalysisResult, error)
+
+	// decodeStream reads a streamed response, writing text to w as it arrives
+	decodeStream(body io.Reader, w io.Writer) (*AnalysisResult, error)
+
+	// decodeModels parses the response of the models endpoint
+	decodeModels(body io.Reader) ([]string, error)
+}
+
+// newBackend returns the backend for an API type
+func newBackend(apiType string) (backend, error) {
+	switch apiType {
+	case APITypeOllama, "":
+		return ollamaBackend{}, nil
+	case APITypeOpenAI:
+		return openAIBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported API type %q: use %s or %s", apiType, APITypeOllama, APITypeOpenAI)
+	}
+}
+
+// ollamaBackend speaks Ollama's native API
+type ollamaBackend struct{}
+
+func (ollamaBackend) generatePath() string { return "/api/generate" }
+
+func (ollamaBackend) modelsPath() string { return "/api/tags" }
+
+func (ollamaBackend) encodeRequest(a *Analyzer, prompt string, stream bool) ([]byte, error) {
+	return json.Marshal(OllamaRequest{
+		Model:   a.Model,
+		Prompt:  prompt,
+		Stream:  stream,
+		Options: a.Options,
+	})
+}
+
+func (ollamaBackend) decodeResponse(body io.Reader) (*AnalysisResult, error) {
+	var ollamaResp OllamaResponse
+	if err := json.NewDecoder(body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if ollamaResp.Error != "" {
+		return nil, fmt.Errorf("ollama returned error: %s", ollamaResp.Error)
+	}
+
+	return &AnalysisResult{
+		Findings:     ollamaResp.Response,
+		PromptTokens: ollamaResp.PromptEvalCount,
+		EvalTokens:   ollamaResp.EvalCount,
+		TokensPerSec: ollamaResp.tokensPerSec(),
+	}, nil
+}
+
+func (ollamaBackend) decodeStream(body io.Reader, w io.Writer) (*AnalysisResult, error) {
+	// The decoder buffers partial lines until a whole JSON object has arrived
+	var findings strings.Builder
+	var chunk OllamaResponse
+	decoder := json.NewDecoder(body)
+	for {
+		chunk = OllamaResponse{}
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("stream ended before completion")
+			}
+			return nil, fmt.Errorf("failed to parse stream: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama returned error: %s", chunk.Error)
+		}
+
+		findings.WriteString(chunk.Response)
+		if _, err := io.WriteString(w, chunk.Response); err != nil {
+			return nil, fmt.Errorf("failed to write stream: %w", err)
+		}
+
+		if chunk.Done {
+			break
+		}
+	}
+
+	// Only the final chunk carries the statistics
+	return &AnalysisResult{
+		Findings:     findings.String(),
+		PromptTokens: chunk.PromptEvalCount,
+		EvalTokens:   chunk.EvalCount,
+		TokensPerSec: chunk.tokensPerSec(),
+	}, nil
+}
+
+func (ollamaBackend) decodeModels(body io.Reader) ([]string, error) {
+	var tags OllamaTagsResponse
+	if err := json.NewDecoder(body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to parse model list: %w", err)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
+
+// OpenAIMessage is a single chat message
+type OpenAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIChatRequest represents a request to an OpenAI-compatible chat completions endpoint
+type OpenAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []OpenAIMessage `json:"messages"`
+	Stream      bool            `json:"stream"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+}
+
+// OpenAIChatResponse represents a chat completions response, or one streamed chunk of it
+type OpenAIChatResponse struct {
+	Choices []struct {
+		Message      OpenAIMessage `json:"message"`
+		Delta        OpenAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// OpenAIModelsResponse represents the list returned by /v1/models
+type OpenAIModelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// openAIBackend speaks the OpenAI-compatible chat completions API
+type openAIBackend struct{}
+
+func (openAIBackend) generatePath() string { return "/v1/chat/completions" }
+
+func (openAIBackend) modelsPath() string { return "/v1/models" }
+
+func (openAIBackend) encodeRequest(a *Analyzer, prompt string, stream bool) ([]byte, error) {
+	req := OpenAIChatRequest{
+		Model:    a.Model,
+		Messages: []OpenAIMessage{{Role: "user", Content: prompt}},
+		Stream:   stream,
+	}
+
+	// Options without an OpenAI equivalent, such as num_ctx, are left to the server
+	if temperature, ok := a.Options["temperature"].(float64); ok {
+		req.Temperature = &temperature
+	}
+	if topP, ok := a.Options["top_p"].(float64); ok {
+		req.TopP = &topP
+	}
+
+	return json.Marshal(req)
+}
+
+func (openAIBackend) decodeResponse(body io.Reader) (*AnalysisResult, error) {
+	var chatResp OpenAIChatResponse
+	if err := json.NewDecoder(body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("server returned error: %s", chatResp.Error.Message)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("response contained no choices")
+	}
+
+	result := &AnalysisResult{Findings: chatResp.Choices[0].Message.Content}
+	if chatResp.Usage != nil {
+		result.PromptTokens = chatResp.Usage.PromptTokens
+		result.EvalTokens = chatResp.Usage.CompletionTokens
+	}
+	return result, nil
+}
+
+func (openAIBackend) decodeStream(body io.Reader, w io.Writer) (*AnalysisResult, error) {
+	// Streamed chunks arrive as server-sent events, one "data:" line each, ending with [DONE]
+	var findings strings.Builder
+	lines := bufio.NewScanner(body)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return &AnalysisResult{Findings: findings.String()}, nil
+		}
+
+		var chunk OpenAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse stream: %w", err)
+		}
+
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("server returned error: %s", chunk.Error.Message)
+		}
+
+		for _, choice := range chunk.Choices {
+			findings.WriteString(choice.Delta.Content)
+			if _, err := io.WriteString(w, choice.Delta.Content); err != nil {
+				return nil, fmt.Errorf("failed to write stream: %w", err)
+			}
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil, fmt.Errorf("stream ended before completion")
+}
+
+func (openAIBackend) decodeModels(body io.Reader) ([]string, error) {
+	var list OpenAIModelsResponse
+	if err := json.NewDecoder(body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to parse model list: %w", err)
+	}
+
+	models := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
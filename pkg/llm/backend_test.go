@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetAPIType(t *testing.T) {
+	analyzer := NewAnalyzer()
+	if analyzer.APIType != APITypeOllama {
+		t.Errorf("expected default API type %s, got %s", APITypeOllama, analyzer.APIType)
+	}
+
+	if err := analyzer.SetAPIType(APITypeOpenAI); err != nil {
+		t.Fatalf("SetAPIType(%q) returned error: %v", APITypeOpenAI, err)
+	}
+	if analyzer.APIType != APITypeOpenAI {
+		t.Errorf("expected API type %s, got %s", APITypeOpenAI, analyzer.APIType)
+	}
+
+	if err := analyzer.SetAPIType("anthropic"); err == nil {
+		t.Error("expected an error for an unknown API type")
+	}
+	if analyzer.APIType != APITypeOpenAI {
+		t.Errorf("expected API type to stay %s after an invalid value, got %s", APITypeOpenAI, analyzer.APIType)
+	}
+}
+
+func TestOpenAIRequestJSON(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.SetModel("qwen2.5-coder")
+	analyzer.SetAPIType(APITypeOpenAI)
+	analyzer.SetTemperature(0.2)
+	analyzer.SetContextWindow(4096)
+
+	req, err := analyzer.newGenerateRequest(context.Background(), "find secrets", false)
+	if err != nil {
+		t.Fatalf("newGenerateRequest() returned error: %v", err)
+	}
+	if req.URL.Path != "/v1/chat/completions" {
+		t.Errorf("expected path /v1/chat/completions, got %s", req.URL.Path)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	if body["model"] != "qwen2.5-coder" {
+		t.Errorf("expected model qwen2.5-coder, got %v", body["model"])
+	}
+	if body["temperature"] != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", body["temperature"])
+	}
+	if _, ok := body["top_p"]; ok {
+		t.Errorf("expected top_p to be omitted when unset, got %v", body["top_p"])
+	}
+	if _, ok := body["options"]; ok {
+		t.Error("expected no Ollama options in an OpenAI request")
+	}
+
+	messages, ok := body["messages"].([]interface{})
+	if !ok || len(messages) != 1 {
+		t.Fatalf("expected one message, got %v", body["messages"])
+	}
+	message := messages[0].(map[string]interface{})
+	if message["role"] != "user" || message["content"] != "find secrets" {
+		t.Errorf("unexpected message: %v", message)
+	}
+}
+
+func TestOpenAIQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req OpenAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Stream {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"No secrets found."},"finish_reason":"stop"}],`+
+			`"usage":{"prompt_tokens":42,"completion_tokens":7}}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetAPIType(APITypeOpenAI)
+
+	result, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if result.Findings != "No secrets found." {
+		t.Errorf("expected findings %q, got %q", "No secrets found.", result.Findings)
+	}
+	if result.PromptTokens != 42 || result.EvalTokens != 7 {
+		t.Errorf("expected 42 prompt and 7 eval tokens, got %d and %d", result.PromptTokens, result.EvalTokens)
+	}
+}
+
+func TestOpenAIQueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error":{"message":"model not loaded"}}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetAPIType(APITypeOpenAI)
+
+	_, err := analyzer.Query("prompt")
+	if err == nil || !strings.Contains(err.Error(), "model not loaded") {
+		t.Errorf("expected the server error to be reported, got %v", err)
+	}
+}
+
+func TestOpenAIQueryStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, token := range []string{"Found ", "one ", "key."} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", token)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetAPIType(APITypeOpenAI)
+
+	var streamed strings.Builder
+	result, err := analyzer.QueryStream("prompt", &streamed)
+	if err != nil {
+		t.Fatalf("QueryStream() returned error: %v", err)
+	}
+	if streamed.String() != "Found one key." {
+		t.Errorf("expected streamed text %q, got %q", "Found one key.", streamed.String())
+	}
+	if result.Findings != "Found one key." {
+		t.Errorf("expected findings %q, got %q", "Found one key.", result.Findings)
+	}
+}
+
+func TestOpenAIListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"llama3"},{"id":"mistral"}]}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetAPIType(APITypeOpenAI)
+
+	models, err := analyzer.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels() returned error: %v", err)
+	}
+	if len(models) != 2 || models[0] != "llama3" || models[1] != "mistral" {
+		t.Errorf("expected [llama3 mistral], got %v", models)
+	}
+}
+
+func TestOpenAIPullModelUnsupported(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.SetAPIType(APITypeOpenAI)
+
+	if err := analyzer.PullModel("llama3", nil); err == nil {
+		t.Error("expected PullModel to fail for the OpenAI API")
+	}
+}
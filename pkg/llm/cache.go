@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is a cached response, stored as one JSON file per model and prompt
+type cacheEntry struct {
+	Model        string        `json:"model"`
+	Findings     string        `json:"findings"`
+	Duration     time.Duration `json:"duration"`
+	PromptTokens int           `json:"prompt_tokens,omitempty"`
+	EvalTokens   int           `json:"eval_tokens,omitempty"`
+	TokensPerSec float64       `json:"tokens_per_sec,omitempty"`
+}
+
+// SetCacheDir stores responses under dir and answers repeated prompts from there. An empty dir disables the cache.
+func (a *Analyzer) SetCacheDir(dir string) {
+	a.CacheDir = dir
+}
+
+// cachePath returns the cache file for a prompt sent to the current model
+func (a *Analyzer) cachePath(prompt string) string {
+	sum := sha256.Sum256([]byte(a.Model + "\x00" + prompt))
+	return filepath.Join(a.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cached returns the stored response to prompt, or nil when there is none for the current model
+func (a *Analyzer) cached(prompt string) *AnalysisResult {
+	if a.CacheDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.cachePath(prompt))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Model != a.Model {
+		return nil
+	}
+
+	return &AnalysisResult{
+		Findings:     entry.Findings,
+		Model:        entry.Model,
+		Duration:     entry.Duration,
+		PromptTokens: entry.PromptTokens,
+		EvalTokens:   entry.EvalTokens,
+		TokensPerSec: entry.TokensPerSec,
+	}
+}
+
+// store saves a response for later runs. The cache is an optimization, so failing to write it is not an error.
+func (a *Analyzer) store(prompt string, result *AnalysisResult) {
+	if a.CacheDir == "" {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		Model:        result.Model,
+		Findings:     result.Findings,
+		Duration:     result.Duration,
+		PromptTokens: result.PromptTokens,
+		EvalTokens:   result.EvalTokens,
+		TokensPerSec: result.TokensPerSec,
+	})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(a.CacheDir, 0700); err != nil {
+		return
+	}
+
+	// Write through a temporary file so concurrent chunks never read a partial entry
+	tmp, err := os.CreateTemp(a.CacheDir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), a.cachePath(prompt)) != nil {
+		os.Remove(tmp.Name())
+	}
+}
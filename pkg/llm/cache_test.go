@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryCache(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"model":"test","response":"answer %d","done":true,"eval_count":5}`+"\n", calls)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+	analyzer.SetCacheDir(t.TempDir())
+
+	first, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	second, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the second query to be served from the cache, got %d requests", calls)
+	}
+	if second.Findings != first.Findings || second.EvalTokens != 5 {
+		t.Errorf("expected the cached response %q with 5 eval tokens, got %q with %d", first.Findings, second.Findings, second.EvalTokens)
+	}
+
+	// A different prompt or model misses the cache
+	if _, err := analyzer.Query("other prompt"); err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	analyzer.SetModel("other-model")
+	result, err := analyzer.Query("prompt")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if calls != 3 || result.Findings != "answer 3" {
+		t.Errorf("expected 3 requests ending in %q, got %d ending in %q", "answer 3", calls, result.Findings)
+	}
+}
+
+func TestQueryWithoutCache(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintln(w, `{"model":"test","response":"answer","done":true}`)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOllamaURL(server.URL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := analyzer.Query("prompt"); err != nil {
+			t.Fatalf("Query() returned error: %v", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected every query to reach the server without a cache, got %d requests", calls)
+	}
+}
@@ -1,5 +1,7 @@
 package llm
 
+import "strconv"
+
 // LogAnalysisPrompt returns the prompt for analyzing log chunks
 func LogAnalysisPrompt(logContent string) string {
 	return `You must respond in English only. Analyze this log chunk and provide a concise summary of key information found.
@@ -16,6 +18,21 @@ Log:
 ` + logContent
 }
 
+// LogRollupPrompt returns the prompt for consolidating the summaries of every chunk of a log into one
+func LogRollupPrompt(chunkSummaries string) string {
+	return `You must respond in English only. The following are summaries of consecutive chunks of one log file.
+Combine them into a single concise summary of the whole log.
+
+- Merge repeated findings into one entry and say how many chunks reported them
+- Leave out chunks that report nothing notable, such as "no errors found"
+- Keep the order in which events happened
+
+Do not provide suggestions, recommendations, or improvements. Only report what the summaries contain.
+
+Chunk summaries:
+` + chunkSummaries
+}
+
 // SecretsAnalysisPrompt returns the prompt for analyzing potential secrets
 func SecretsAnalysisPrompt(fileContent string) string {
 	return `Analyze the following detected secrets and provide a detailed security assessment.
@@ -164,7 +181,7 @@ func ContextualSecurityPrompt(filename string, lineNumber int, secretType string
 	return `Analyze this specific secret finding in context.
 
 File: ` + filename + `
-Line: ` + string(rune(lineNumber)) + `
+Line: ` + strconv.Itoa(lineNumber) + `
 Detected Type: ` + secretType + `
 
 Context (surrounding code):
@@ -0,0 +1,21 @@
+package llm
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestContextualSecurityPromptLineNumber(t *testing.T) {
+	for _, lineNumber := range []int{10, 65, 1234} {
+		prompt := ContextualSecurityPrompt("config/app.env", lineNumber, "AWS Access Key", "aws_access_key_id = AKIA...")
+
+		if want := "Line: " + strconv.Itoa(lineNumber) + "\n"; !strings.Contains(prompt, want) {
+			t.Errorf("line %d: prompt missing %q:\n%s", lineNumber, want, prompt)
+		}
+
+		if !strings.Contains(prompt, "File: config/app.env\n") {
+			t.Errorf("line %d: prompt missing the filename:\n%s", lineNumber, prompt)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// contentProbe is rendered in place of the content to check that a template uses it
+const contentProbe = "\x00goscout-content\x00"
+
+// PromptData is what a custom prompt template is rendered with
+type PromptData struct {
+	// Content is the log chunk, the findings of a scan, or the code around a single finding
+	Content string
+
+	// File, Line and SecretType describe the finding when each finding is analyzed on its own
+	File       string
+	Line       int
+	SecretType string
+}
+
+// PromptTemplate is a user-supplied prompt written as a Go text/template
+type PromptTemplate struct {
+	tmpl *template.Template
+}
+
+// LoadPromptTemplate reads and validates a prompt template from a file
+func LoadPromptTemplate(path string) (*PromptTemplate, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	return ParsePromptTemplate(path, string(text))
+}
+
+// ParsePromptTemplate parses a prompt template. It must render {{.Content}}, otherwise the model
+// would never see what it is asked to analyze.
+func ParsePromptTemplate(name, text string) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %w", err)
+	}
+
+	p := &PromptTemplate{tmpl: tmpl}
+	probe, err := p.Render(PromptData{Content: contentProbe})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(probe, contentProbe) {
+		return nil, fmt.Errorf("prompt template %s does not use the {{.Content}} placeholder", name)
+	}
+
+	return p, nil
+}
+
+// Render fills the template in with data
+func (p *PromptTemplate) Render(data PromptData) (string, error) {
+	var sb strings.Builder
+	if err := p.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// SetPromptTemplate replaces the built-in prompts with a custom template. Nil restores the built-in ones.
+func (a *Analyzer) SetPromptTemplate(p *PromptTemplate) {
+	a.Template = p
+}
+
+// Prompt returns the custom template rendered with data, or builtin when no template is set
+func (a *Analyzer) Prompt(data PromptData, builtin string) (string, error) {
+	if a.Template == nil {
+		return builtin, nil
+	}
+	return a.Template.Render(data)
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePromptTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		data    PromptData
+		want    string
+		wantErr string
+	}{
+		{
+			name: "content and finding fields",
+			text: "Review {{.SecretType}} in {{.File}}:{{.Line}}\n{{.Content}}",
+			data: PromptData{Content: "token = abc", File: "app.env", Line: 3, SecretType: "Generic API Key"},
+			want: "Review Generic API Key in app.env:3\ntoken = abc",
+		},
+		{
+			name:    "no content placeholder",
+			text:    "Summarize {{.File}}",
+			wantErr: "does not use the {{.Content}} placeholder",
+		},
+		{
+			name:    "unknown field",
+			text:    "{{.Content}} {{.Severity}}",
+			wantErr: "failed to render prompt template",
+		},
+		{
+			name:    "syntax error",
+			text:    "{{.Content",
+			wantErr: "invalid prompt template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParsePromptTemplate("custom.tmpl", tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePromptTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePromptTemplate() error = %v", err)
+			}
+
+			got, err := tmpl.Render(tt.data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPromptTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Find failed logins:\n{{.Content}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := LoadPromptTemplate(path)
+	if err != nil {
+		t.Fatalf("LoadPromptTemplate() error = %v", err)
+	}
+
+	got, err := tmpl.Render(PromptData{Content: "sshd: Failed password for root"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Find failed logins:\nsshd: Failed password for root"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := LoadPromptTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("LoadPromptTemplate() on a missing file should fail")
+	}
+}
+
+func TestAnalyzerPrompt(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	got, err := analyzer.Prompt(PromptData{Content: "log line"}, "built-in prompt")
+	if err != nil || got != "built-in prompt" {
+		t.Errorf("Prompt() without a template = %q, %v, want the built-in prompt", got, err)
+	}
+
+	tmpl, err := ParsePromptTemplate("custom", "Custom: {{.Content}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer.SetPromptTemplate(tmpl)
+
+	got, err = analyzer.Prompt(PromptData{Content: "log line"}, "built-in prompt")
+	if err != nil || got != "Custom: log line" {
+		t.Errorf("Prompt() with a template = %q, %v, want %q", got, err, "Custom: log line")
+	}
+}
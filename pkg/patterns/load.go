@@ -0,0 +1,200 @@
+package patterns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// patternFile is the layout of a YAML or JSON patterns file
+type patternFile struct {
+	Patterns []patternSpec `yaml:"patterns" json:"patterns"`
+}
+
+// patternSpec describes one custom pattern in a patterns file
+type patternSpec struct {
+	Name           string `yaml:"name" json:"name"`
+	Description    string `yaml:"description" json:"description"`
+	Regex          string `yaml:"regex" json:"regex"`
+	Severity       string `yaml:"severity" json:"severity"`
+	Category       string `yaml:"category" json:"category"`
+	Remediation    string `yaml:"remediation" json:"remediation"`
+	CWE            string `yaml:"cwe" json:"cwe"`
+	SecretGroup    int    `yaml:"secret_group" json:"secret_group"`
+	MultiLine      bool   `yaml:"multi_line" json:"multi_line"`
+	RequireKeyword bool   `yaml:"require_keyword" json:"require_keyword"`
+}
+
+// LoadFile reads custom patterns from a YAML or JSON file, picked by its extension:
+//
+//	patterns:
+//	  - name: Internal Token
+//	    regex: 'itk_[a-z0-9]{32}'
+//	    severity: high
+func LoadFile(path string) ([]Pattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patterns file: %w", err)
+	}
+
+	var file patternFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid patterns file %s: %w", path, err)
+	}
+
+	loaded := make([]Pattern, 0, len(file.Patterns))
+	for i, spec := range file.Patterns {
+		p, err := spec.pattern()
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %d in %s: %w", i+1, path, err)
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded, nil
+}
+
+// pattern validates a spec and compiles it into a Pattern
+func (spec patternSpec) pattern() (Pattern, error) {
+	if strings.TrimSpace(spec.Name) == "" {
+		return Pattern{}, fmt.Errorf("name is required")
+	}
+
+	regex, err := regexp.Compile(spec.Regex)
+	if err != nil || spec.Regex == "" {
+		return Pattern{}, fmt.Errorf("%s: invalid regex %q", spec.Name, spec.Regex)
+	}
+
+	severity := strings.ToLower(spec.Severity)
+	if SeverityRank(severity) == 0 {
+		return Pattern{}, fmt.Errorf("%s: severity must be low, medium or high, got %q", spec.Name, spec.Severity)
+	}
+
+	category := strings.ToLower(spec.Category)
+	if category == "" {
+		category = CategoryGeneric
+	}
+	if !utils.ContainsString(Categories, category) {
+		return Pattern{}, fmt.Errorf("%s: unknown category %q (use %s)", spec.Name, spec.Category, strings.Join(Categories, ", "))
+	}
+
+	if spec.SecretGroup < 0 || spec.SecretGroup > regex.NumSubexp() {
+		return Pattern{}, fmt.Errorf("%s: secret_group %d but the regex has %d groups", spec.Name, spec.SecretGroup, regex.NumSubexp())
+	}
+
+	description := spec.Description
+	if description == "" {
+		description = spec.Name
+	}
+
+	return Pattern{
+		Name:           spec.Name,
+		Description:    description,
+		Regex:          regex,
+		Severity:       severity,
+		Category:       category,
+		Remediation:    spec.Remediation,
+		CWE:            spec.CWE,
+		SecretGroup:    spec.SecretGroup,
+		MultiLine:      spec.MultiLine,
+		RequireKeyword: spec.RequireKeyword,
+		Enabled:        true,
+	}, nil
+}
+
+// LoadPaths loads custom patterns from files and directories, in order. A directory contributes its
+// .yaml, .yml and .json files sorted by name. When a name is defined again, the later definition wins
+// and a warning is returned, or loading fails when strict is set.
+func LoadPaths(paths []string, strict bool) (loaded []Pattern, warnings []string, err error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read patterns: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read patterns directory: %w", err)
+		}
+		var dirFiles []string
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					dirFiles = append(dirFiles, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+
+	definedIn := make(map[string]string)
+	index := make(map[string]int)
+	for _, file := range files {
+		list, err := LoadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, p := range list {
+			key := p.ID()
+			if previous, found := definedIn[key]; found {
+				if strict {
+					return nil, nil, fmt.Errorf("pattern %q in %s is already defined in %s", p.Name, file, previous)
+				}
+				warnings = append(warnings, fmt.Sprintf("pattern %q in %s overrides the one in %s", p.Name, file, previous))
+				loaded[index[key]] = p
+				definedIn[key] = file
+				continue
+			}
+
+			definedIn[key] = file
+			index[key] = len(loaded)
+			loaded = append(loaded, p)
+		}
+	}
+	return loaded, warnings, nil
+}
+
+// Merge adds custom patterns to base. A custom pattern with the name of a base pattern replaces it in place.
+func Merge(base, custom []Pattern) []Pattern {
+	merged := append([]Pattern(nil), base...)
+	for _, p := range custom {
+		replaced := false
+		for i := range merged {
+			if merged[i].ID() == p.ID() {
+				merged[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
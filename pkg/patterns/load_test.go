@@ -0,0 +1,125 @@
+package patterns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const basePatterns = `patterns:
+  - name: Internal Token
+    regex: 'itk_[a-z0-9]{32}'
+    severity: high
+    category: cloud
+  - name: Build Key
+    regex: 'bk-[0-9]{8}'
+    severity: low
+`
+
+const teamPatterns = `{
+  "patterns": [
+    {"name": "internal token", "regex": "itk_[a-z0-9]{40}", "severity": "medium"},
+    {"name": "Team Secret", "regex": "team_([A-Z]{10})", "severity": "high", "secret_group": 1}
+  ]
+}
+`
+
+func writePatternsFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write patterns file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPathsOverride(t *testing.T) {
+	dir := t.TempDir()
+	base := writePatternsFile(t, dir, "base.yaml", basePatterns)
+	team := writePatternsFile(t, dir, "team.json", teamPatterns)
+
+	loaded, warnings, err := LoadPaths([]string{base, team}, false)
+	if err != nil {
+		t.Fatalf("LoadPaths() returned error: %v", err)
+	}
+
+	var names []string
+	for _, p := range loaded {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "internal token,Build Key,Team Secret" {
+		t.Fatalf("expected the override in place of the base pattern, got %s", got)
+	}
+	if loaded[0].Severity != "medium" || loaded[0].Category != CategoryGeneric {
+		t.Errorf("expected the later definition to win, got %+v", loaded[0])
+	}
+	if loaded[2].SecretGroup != 1 || !loaded[2].Enabled {
+		t.Errorf("expected Team Secret to keep its secret group and be enabled, got %+v", loaded[2])
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], team) || !strings.Contains(warnings[0], base) {
+		t.Errorf("expected one warning naming both files, got %v", warnings)
+	}
+
+	if _, _, err := LoadPaths([]string{base, team}, true); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("expected strict loading to fail on the duplicate, got %v", err)
+	}
+}
+
+func TestLoadPathsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writePatternsFile(t, dir, "b-team.json", teamPatterns)
+	writePatternsFile(t, dir, "a-base.yaml", basePatterns)
+	writePatternsFile(t, dir, "README.md", "not patterns")
+
+	loaded, warnings, err := LoadPaths([]string{dir}, false)
+	if err != nil {
+		t.Fatalf("LoadPaths() returned error: %v", err)
+	}
+	if len(loaded) != 3 || len(warnings) != 1 {
+		t.Fatalf("expected 3 patterns and 1 warning, got %d and %v", len(loaded), warnings)
+	}
+	if loaded[0].Name != "internal token" {
+		t.Errorf("expected files loaded in name order so b-team.json wins, got %q", loaded[0].Name)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"missing name", "patterns:\n  - regex: 'x'\n    severity: low\n", "name is required"},
+		{"bad regex", "patterns:\n  - name: A\n    regex: '('\n    severity: low\n", "invalid regex"},
+		{"bad severity", "patterns:\n  - name: A\n    regex: 'x'\n    severity: urgent\n", "severity"},
+		{"bad category", "patterns:\n  - name: A\n    regex: 'x'\n    severity: low\n    category: misc\n", "unknown category"},
+		{"bad secret group", "patterns:\n  - name: A\n    regex: 'x'\n    severity: low\n    secret_group: 2\n", "secret_group"},
+		{"unknown field", "patterns:\n  - name: A\n    regex: 'x'\n    severity: low\n    colour: red\n", "colour"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePatternsFile(t, dir, "patterns.yaml", tt.content)
+			_, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFile() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := []Pattern{{Name: "AWS Access Key", Severity: "high"}, {Name: "Slack Token"}}
+	custom := []Pattern{{Name: "aws access key", Severity: "low"}, {Name: "Internal Token"}}
+
+	merged := Merge(base, custom)
+	if len(merged) != 3 || merged[0].Severity != "low" || merged[2].Name != "Internal Token" {
+		t.Errorf("unexpected merge result %+v", merged)
+	}
+	if base[0].Severity != "high" {
+		t.Error("Merge modified the base slice")
+	}
+}
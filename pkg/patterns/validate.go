@@ -0,0 +1,46 @@
+package patterns
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"strings"
+)
+
+// awsKeyIDPrefixes are the four-letter prefixes AWS uses for access key IDs
+var awsKeyIDPrefixes = []string{"AKIA", "ASIA", "ABIA", "ACCA"}
+
+// maxAWSAccountID is the largest 12-digit AWS account ID
+const maxAWSAccountID = 999999999999
+
+// ValidAWSAccessKeyID reports whether s has the structure of a real AWS access key ID.
+// AWS does not publish a checksum, but after the prefix a key ID is base32 and encodes the
+// owning account ID, so strings using other characters, lowercase letters or decoding to an
+// impossible account are rejected.
+func ValidAWSAccessKeyID(s string) bool {
+	if len(s) != 20 {
+		return false
+	}
+
+	prefixed := false
+	for _, prefix := range awsKeyIDPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			prefixed = true
+			break
+		}
+	}
+	if !prefixed {
+		return false
+	}
+
+	// Sixteen base32 characters decode to ten bytes
+	decoded, err := base32.StdEncoding.DecodeString(s[4:])
+	if err != nil {
+		return false
+	}
+
+	// The account ID sits in bits 7 to 46 of the first six bytes
+	var buf [8]byte
+	copy(buf[2:], decoded[:6])
+	account := (binary.BigEndian.Uint64(buf[:]) & 0x7fffffffff80) >> 7
+	return account <= maxAWSAccountID
+}
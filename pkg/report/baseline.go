@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// Baseline is a set of accepted findings that should not be reported again
+type Baseline struct {
+	Findings []*BaselineEntry `json:"findings"`
+
+	fingerprints map[string]bool
+}
+
+// BaselineEntry records one accepted finding
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	FilePath    string `json:"file_path"`
+	PatternName string `json:"pattern_name"`
+}
+
+// GenerateBaseline writes the given findings as a baseline file
+func (r *Report) GenerateBaseline(matches []*scanner.Match) error {
+	baseline := &Baseline{
+		Findings: make([]*BaselineEntry, len(matches)),
+	}
+
+	for i, match := range matches {
+		baseline.Findings[i] = &BaselineEntry{
+			Fingerprint: match.Fingerprint(),
+			FilePath:    match.FilePath,
+			PatternName: match.Pattern.Name,
+		}
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(baseline)
+}
+
+// LoadBaseline reads a baseline file from disk
+func LoadBaseline(path string) (*Baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open baseline: %w", err)
+	}
+	defer file.Close()
+
+	return ReadBaseline(file)
+}
+
+// ReadBaseline parses a baseline from r
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var baseline Baseline
+	if err := json.NewDecoder(r).Decode(&baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+
+	baseline.fingerprints = make(map[string]bool, len(baseline.Findings))
+	for _, entry := range baseline.Findings {
+		baseline.fingerprints[entry.Fingerprint] = true
+	}
+
+	return &baseline, nil
+}
+
+// Contains reports whether the finding is part of the baseline
+func (b *Baseline) Contains(match *scanner.Match) bool {
+	return b.fingerprints[match.Fingerprint()]
+}
+
+// Filter returns the matches that are not part of the baseline
+func (b *Baseline) Filter(matches []*scanner.Match) []*scanner.Match {
+	filtered := make([]*scanner.Match, 0, len(matches))
+	for _, match := range matches {
+		if !b.Contains(match) {
+			filtered = append(filtered, match)
+		}
+	}
+	return filtered
+}
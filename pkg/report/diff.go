@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// Diff is the change in findings since a previous JSON report
+type Diff struct {
+	New   []*scanner.Match // findings not in the previous report
+	Fixed []*MatchReport   // previous findings the scan no longer produces
+}
+
+// LoadJSONReport reads a JSON secrets report written by an earlier run
+func LoadJSONReport(path string) (*JSONReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open previous report: %w", err)
+	}
+	defer file.Close()
+
+	return ReadJSONReport(file)
+}
+
+// ReadJSONReport parses a JSON secrets report from r
+func ReadJSONReport(r io.Reader) (*JSONReport, error) {
+	var report JSONReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse previous report: %w", err)
+	}
+	return &report, nil
+}
+
+// DiffReport compares findings to a previous report by fingerprint, so findings that only moved
+// to another line are neither new nor fixed
+func DiffReport(previous *JSONReport, matches []*scanner.Match) *Diff {
+	before := make(map[string]bool, len(previous.Matches))
+	for _, match := range previous.Matches {
+		before[match.Fingerprint] = true
+	}
+
+	diff := &Diff{New: make([]*scanner.Match, 0)}
+	now := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		fingerprint := match.Fingerprint()
+		now[fingerprint] = true
+		if !before[fingerprint] {
+			diff.New = append(diff.New, match)
+		}
+	}
+
+	for _, match := range previous.Matches {
+		if !now[match.Fingerprint] {
+			diff.Fixed = append(diff.Fixed, match)
+		}
+	}
+
+	return diff
+}
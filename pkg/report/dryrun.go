@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// JSONDryRunReport represents the JSON output of a dry run
+type JSONDryRunReport struct {
+	Files   []string          `json:"files"`
+	Skipped []*JSONSkipReport `json:"skipped"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// JSONSkipReport is a file or directory a dry run would skip
+type JSONSkipReport struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// GenerateDryRun lists the files a scan would read and those it would skip with the reason.
+// Formats other than JSON and table are written as text.
+func (r *Report) GenerateDryRun(result *scanner.DryRunResult) error {
+	switch r.format {
+	case "json":
+		return r.generateDryRunJSON(result)
+	case "table":
+		return r.generateDryRunTable(result)
+	}
+
+	fmt.Fprintf(r.writer, "Files to scan: %d\n", len(result.Files))
+	for _, file := range result.Files {
+		fmt.Fprintf(r.writer, "  ✓ %s\n", file)
+	}
+	fmt.Fprintf(r.writer, "\nSkipped: %d\n", len(result.Skipped))
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(r.writer, "  - %s (%s)\n", skipped.Path, skipped.Reason)
+	}
+	r.scanErrors = result.Errors
+	r.writeErrors()
+	return nil
+}
+
+// generateDryRunJSON writes a dry run as a single JSON object
+func (r *Report) generateDryRunJSON(result *scanner.DryRunResult) error {
+	report := &JSONDryRunReport{
+		Files:   result.Files,
+		Skipped: make([]*JSONSkipReport, 0, len(result.Skipped)),
+	}
+	if report.Files == nil {
+		report.Files = []string{}
+	}
+	for _, skipped := range result.Skipped {
+		report.Skipped = append(report.Skipped, &JSONSkipReport{Path: skipped.Path, Reason: skipped.Reason})
+	}
+	for _, err := range result.Errors {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// generateDryRunTable prints one row per file, scanned or skipped
+func (r *Report) generateDryRunTable(result *scanner.DryRunResult) error {
+	fmt.Fprintf(r.writer, "%-60s | %-25s\n", "File", "Status")
+	fmt.Fprintf(r.writer, "%-60s-+-%-25s\n",
+		"────────────────────────────────────────────────────────────",
+		"─────────────────────────")
+
+	for _, file := range result.Files {
+		fmt.Fprintf(r.writer, "%-60s | %-25s\n", tablePath(file, 60), "scan")
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(r.writer, "%-60s | %-25s\n", tablePath(skipped.Path, 60), "skip: "+skipped.Reason)
+	}
+
+	fmt.Fprintf(r.writer, "\n")
+	fmt.Fprintf(r.writer, "Files to scan: %d\n", len(result.Files))
+	fmt.Fprintf(r.writer, "Skipped: %d\n", len(result.Skipped))
+	return nil
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// htmlFileGroup is the findings of one file in the HTML report
+type htmlFileGroup struct {
+	FilePath string
+	Rows     []htmlRow
+}
+
+// htmlRow is one finding, with its content masked as the report requires
+type htmlRow struct {
+	LineNumber int
+	Pattern    string
+	Severity   string
+	Content    string
+	Secret     string
+}
+
+// htmlPage is the data rendered by htmlTemplate
+type htmlPage struct {
+	Title        string
+	Version      string
+	GeneratedAt  string
+	Roots        []string
+	ShowFindings bool // false for an analysis on its own page
+	Summary      *Summary
+	FilesScanned int
+	FilesSkipped int
+	SummaryOnly  bool
+	Groups       []htmlFileGroup
+	Analyses     []*AnalysisReport
+}
+
+// htmlTemplate renders a self-contained page. html/template escapes file content and model output,
+// so a finding cannot inject markup into the report.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"trim":     strings.TrimSpace,
+	"truncate": truncate,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.6em; }
+.meta { color: #656d76; }
+.summary { display: flex; gap: 1em; margin: 1em 0; }
+.summary div { border-radius: 6px; padding: 0.6em 1em; background: #f6f8fa; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+tr.file td { background: #eef1f4; font-weight: bold; }
+code, pre { font-family: SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; }
+pre { white-space: pre-wrap; background: #f6f8fa; padding: 1em; border-radius: 6px; }
+.high { color: #cf222e; font-weight: bold; }
+.medium { color: #9a6700; font-weight: bold; }
+.low { color: #1a7f37; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>🔍 {{.Title}}</h1>
+<p class="meta">Generated {{.GeneratedAt}}{{if .Version}} by goscout {{.Version}}{{end}}{{if .Roots}} · Scanned: {{range $i, $root := .Roots}}{{if $i}}, {{end}}<code>{{$root}}</code>{{end}}{{end}}</p>
+{{- if .ShowFindings}}
+<div class="summary">
+<div><strong>{{.Summary.TotalMatches}}</strong> findings</div>
+<div class="high">🔴 High: {{.Summary.HighSeverity}}</div>
+<div class="medium">🟡 Medium: {{.Summary.MediumSeverity}}</div>
+<div class="low">🟢 Low: {{.Summary.LowSeverity}}</div>
+<div>Files scanned: {{.FilesScanned}} · skipped: {{.FilesSkipped}}</div>
+</div>
+{{- if not .Summary.TotalMatches}}
+<p>✅ No secrets found.</p>
+{{- else if not .SummaryOnly}}
+<h2>Findings</h2>
+<table id="findings">
+<thead><tr><th>Line</th><th>Pattern</th><th>Severity</th><th>Content</th><th>Match</th></tr></thead>
+{{- range .Groups}}
+<tbody>
+<tr class="file"><td colspan="5">📄 {{.FilePath}}</td></tr>
+{{- range .Rows}}
+<tr class="finding"><td>{{.LineNumber}}</td><td>{{.Pattern}}</td><td class="{{.Severity}}">{{.Severity}}</td><td><code>{{truncate (trim .Content) 120}}</code></td><td><code>{{truncate .Secret 60}}</code></td></tr>
+{{- end}}
+</tbody>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- range .Analyses}}
+<h2>📊 {{.Title}}</h2>
+<p class="meta">Model: {{.Model}} · Duration: {{.Duration}} · Timestamp: {{.Timestamp}}</p>
+<pre>{{trim .Content}}</pre>
+{{- end}}
+<script>
+// Clicking a column header sorts the findings of each file by that column
+document.querySelectorAll("#findings th").forEach(function (th, column) {
+  var ascending = true;
+  th.addEventListener("click", function () {
+    document.querySelectorAll("#findings tbody").forEach(function (body) {
+      var rows = Array.from(body.querySelectorAll("tr.finding"));
+      rows.sort(function (a, b) {
+        var x = a.cells[column].textContent, y = b.cells[column].textContent;
+        var order = isNaN(x) || isNaN(y) ? x.localeCompare(y) : x - y;
+        return ascending ? order : -order;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+    ascending = !ascending;
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// AddAnalysis embeds an AI analysis in an HTML, JSON, SARIF or JUnit secrets report
+func (r *Report) AddAnalysis(analysis *AnalysisReport) {
+	r.analyses = append(r.analyses, analysis)
+}
+
+// generateSecretsHTML generates a self-contained HTML page with the findings grouped by file
+func (r *Report) generateSecretsHTML(matches []*scanner.Match, filesScanned, filesSkipped int) error {
+	r.sortMatches(matches)
+
+	page := &htmlPage{
+		Title:        "GoScout Secret Scan",
+		Version:      r.version,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		Roots:        r.displayRoots(),
+		ShowFindings: true,
+		Summary:      summarize(matches),
+		FilesScanned: filesScanned,
+		FilesSkipped: filesSkipped,
+		SummaryOnly:  r.summaryOnly,
+		Groups:       r.groupByFile(matches),
+		Analyses:     r.analyses,
+	}
+
+	for _, analysis := range page.Analyses {
+		if analysis.Timestamp == "" {
+			analysis.Timestamp = page.GeneratedAt
+		}
+	}
+
+	return htmlTemplate.Execute(r.writer, page)
+}
+
+// generateAnalysisHTML renders AI analyses on a page of their own
+func (r *Report) generateAnalysisHTML(analyses ...*AnalysisReport) error {
+	title := "GoScout AI Analysis"
+	if len(analyses) == 1 {
+		title = analyses[0].Title
+	}
+
+	return htmlTemplate.Execute(r.writer, &htmlPage{
+		Title:       title,
+		Version:     r.version,
+		GeneratedAt: analyses[0].Timestamp,
+		Roots:       r.displayRoots(),
+		Analyses:    analyses,
+	})
+}
+
+// groupByFile splits sorted matches into runs that share a file
+func (r *Report) groupByFile(matches []*scanner.Match) []htmlFileGroup {
+	var groups []htmlFileGroup
+	for _, match := range matches {
+		if len(groups) == 0 || groups[len(groups)-1].FilePath != match.FilePath {
+			groups = append(groups, htmlFileGroup{FilePath: match.FilePath})
+		}
+		last := &groups[len(groups)-1]
+		last.Rows = append(last.Rows, htmlRow{
+			LineNumber: match.LineNumber,
+			Pattern:    match.Pattern.Name,
+			Severity:   match.Pattern.Severity,
+			Content:    r.line(match, match.LineContent),
+			Secret:     r.secret(match),
+		})
+	}
+	return groups
+}
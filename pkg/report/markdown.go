@@ -0,0 +1,85 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// generateSecretsMarkdown generates a GitHub-flavored Markdown report suitable for PR comments
+func (r *Report) generateSecretsMarkdown(matches []*scanner.Match, filesScanned, filesSkipped int) error {
+	fmt.Fprintf(r.writer, "## 🔍 GoScout Secret Scan\n\n")
+
+	if len(matches) == 0 {
+		fmt.Fprintf(r.writer, "✅ No secrets found. Scanned %d files (%d skipped).\n", filesScanned, filesSkipped)
+		return nil
+	}
+
+	highCount, mediumCount, lowCount := 0, 0, 0
+	for _, match := range matches {
+		switch match.Pattern.Severity {
+		case "high":
+			highCount++
+		case "medium":
+			mediumCount++
+		case "low":
+			lowCount++
+		}
+	}
+
+	fmt.Fprintf(r.writer, "⚠️ **%d secrets found** in %d files scanned (%d skipped).\n\n", len(matches), filesScanned, filesSkipped)
+	fmt.Fprintf(r.writer, "| Severity | Count |\n")
+	fmt.Fprintf(r.writer, "| --- | ---: |\n")
+	fmt.Fprintf(r.writer, "| 🔴 High | %d |\n", highCount)
+	fmt.Fprintf(r.writer, "| 🟡 Medium | %d |\n", mediumCount)
+	fmt.Fprintf(r.writer, "| 🟢 Low | %d |\n\n", lowCount)
+
+	fmt.Fprintf(r.writer, "### Findings\n\n")
+	fmt.Fprintf(r.writer, "| File | Line | Pattern | Severity | Content |\n")
+	fmt.Fprintf(r.writer, "| --- | ---: | --- | --- | --- |\n")
+
+	for _, match := range matches {
+		fmt.Fprintf(r.writer, "| %s | %d | %s | %s | %s |\n",
+			markdownCode(match.FilePath),
+			match.LineNumber,
+			markdownCell(match.Pattern.Name),
+			match.Pattern.Severity,
+			markdownCode(truncate(strings.TrimSpace(r.line(match, match.LineContent)), 80)))
+	}
+
+	return nil
+}
+
+// generateAnalysisMarkdown renders an AI analysis with the model output in a collapsible block
+func (r *Report) generateAnalysisMarkdown(analysis *AnalysisReport) error {
+	fmt.Fprintf(r.writer, "## 📊 %s\n\n", analysis.Title)
+	fmt.Fprintf(r.writer, "**Model:** %s · **Duration:** %s · **Timestamp:** %s\n\n", analysis.Model, analysis.Duration, analysis.Timestamp)
+	fmt.Fprintf(r.writer, "<details>\n<summary>AI analysis</summary>\n\n")
+	fmt.Fprint(r.writer, strings.TrimSpace(analysis.Content))
+	fmt.Fprintf(r.writer, "\n\n</details>\n")
+	if stats := analysis.statsLine(); stats != "" {
+		fmt.Fprintf(r.writer, "\n_%s_\n", stats)
+	}
+	return nil
+}
+
+// markdownCell escapes characters that would break a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// markdownCode renders s as inline code inside a table cell
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	// A backtick inside the value needs a longer fence
+	fence := "`"
+	if strings.Contains(s, "`") {
+		fence = "``"
+		s = " " + s + " "
+	}
+	return fence + markdownCell(s) + fence
+}
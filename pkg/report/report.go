@@ -4,33 +4,117 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/deadrootsec/goscout/pkg/patterns"
 	"github.com/deadrootsec/goscout/pkg/scanner"
+	"github.com/deadrootsec/goscout/pkg/utils"
 	"github.com/fatih/color"
 )
 
+const (
+	// GroupByFile lists findings under the file they were found in
+	GroupByFile = "file"
+
+	// GroupByPattern lists findings under the pattern that matched them
+	GroupByPattern = "pattern"
+)
+
 // Report handles all report generation and output
 type Report struct {
-	writer io.Writer
-	format string
+	writer  io.Writer
+	format  string
+	version string
+	roots   []string
+	groupBy string
+
+	// summaryOnly leaves out the individual findings
+	summaryOnly bool
+
+	// showSecrets prints secret values in full instead of masking them
+	showSecrets bool
+
+	// relativePaths reports files relative to their scan root and shortens the home directory to ~
+	relativePaths bool
+
+	// omitLineContent and omitMatch leave the line and the secret out of JSON findings
+	omitLineContent bool
+	omitMatch       bool
+
+	// analyses are embedded in HTML, JSON, SARIF and JUnit secrets reports
+	analyses []*AnalysisReport
+
+	// scanErrors are the files that could not be scanned, listed so they are not mistaken for clean ones
+	scanErrors []error
+
+	// cappedFiles reached the per-file match limit of matchLimit, so their findings are incomplete
+	cappedFiles []string
+	matchLimit  int
+
+	// fixed are the findings of a previous report that are gone, listed when diffing
+	fixed []*MatchReport
+
+	// colored is false when the writer is not a terminal or NO_COLOR is set
+	colored bool
 }
 
 // JSONReport represents the JSON output format
 type JSONReport struct {
+	Meta    *Meta          `json:"meta"`
 	Summary *Summary       `json:"summary"`
 	Matches []*MatchReport `json:"matches"`
 	Stats   *Stats         `json:"stats"`
+	Errors  []string       `json:"errors,omitempty"`
+
+	// CappedFiles reached the per-file match limit; matches past it are not listed
+	CappedFiles []string `json:"capped_files,omitempty"`
+
+	// Fixed lists findings of the previous report that are gone, when diffing against one
+	Fixed []*MatchReport `json:"fixed,omitempty"`
+
+	// Analysis holds the AI analyses of the findings, when they share the report
+	Analysis []*JSONAnalysisReport `json:"analysis,omitempty"`
+}
+
+// JSONAnalysisReport represents the JSON output format of an AI analysis
+type JSONAnalysisReport struct {
+	Title        string  `json:"title"`
+	Model        string  `json:"model"`
+	Duration     string  `json:"duration"`
+	Timestamp    string  `json:"timestamp"`
+	Content      string  `json:"content"`
+	PromptTokens int     `json:"prompt_tokens,omitempty"`
+	EvalTokens   int     `json:"eval_tokens,omitempty"`
+	TokensPerSec float64 `json:"tokens_per_sec,omitempty"`
 }
 
 // MatchReport represents a match in the report
 type MatchReport struct {
 	FilePath    string `json:"file_path"`
 	LineNumber  int    `json:"line_number"`
+	Column      int    `json:"column,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
 	PatternName string `json:"pattern_name"`
 	Severity    string `json:"severity"`
-	Match       string `json:"match"`
-	LineContent string `json:"line_content"`
+	Match       string `json:"match,omitempty"`
+	LineContent string `json:"line_content,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+	CWE         string `json:"cwe,omitempty"`
+	OWASP       string `json:"owasp,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Commit      string `json:"commit,omitempty"`
+}
+
+// Meta identifies the tool and the scan that produced a report
+type Meta struct {
+	Tool      string   `json:"tool"`
+	Version   string   `json:"version"`
+	ScannedAt string   `json:"scanned_at"`
+	Roots     []string `json:"roots"`
 }
 
 // Summary contains scan summary information
@@ -53,24 +137,298 @@ type AnalysisReport struct {
 	Model     string
 	Content   string
 	Duration  string
-	Timestamp string
+	Timestamp string // RFC3339 in UTC, set to the current time by GenerateAnalysis when empty
+
+	// Token usage, printed as a footer when EvalTokens is set
+	PromptTokens int
+	EvalTokens   int
+	TokensPerSec float64
+}
+
+// statsLine summarizes token usage, or returns "" when the model reported none
+func (a *AnalysisReport) statsLine() string {
+	if a.EvalTokens == 0 {
+		return ""
+	}
+
+	line := fmt.Sprintf("Tokens: %d prompt, %d generated", a.PromptTokens, a.EvalTokens)
+	if a.TokensPerSec > 0 {
+		line += fmt.Sprintf(" (%.1f tokens/s)", a.TokensPerSec)
+	}
+	return line
 }
 
 // NewReport creates a new report
 func NewReport(writer io.Writer, format string) *Report {
 	return &Report{
-		writer: writer,
-		format: format,
+		writer:  writer,
+		format:  format,
+		colored: colorEnabled(writer),
+	}
+}
+
+// SetVersion sets the goscout version recorded in formats that identify the tool
+func (r *Report) SetVersion(version string) {
+	r.version = version
+}
+
+// SetRoots sets the paths that were scanned, recorded in the JSON report
+func (r *Report) SetRoots(roots []string) {
+	r.roots = roots
+}
+
+// SetGroupBy sets how the text and table reports group findings: GroupByFile (the default) or GroupByPattern
+func (r *Report) SetGroupBy(groupBy string) {
+	r.groupBy = groupBy
+}
+
+// SetSummaryOnly makes secret reports print only the severity counts and file statistics
+func (r *Report) SetSummaryOnly(summaryOnly bool) {
+	r.summaryOnly = summaryOnly
+}
+
+// SetShowSecrets prints secret values in full. By default they are masked, keeping only their first
+// characters, so reports can be shared and screenshotted safely.
+func (r *Report) SetShowSecrets(show bool) {
+	r.showSecrets = show
+}
+
+// SetRelativePaths reports each file relative to the root it was scanned under, and shortens the home
+// directory to ~ in paths that stay absolute, so reports don't reveal where the scan ran
+func (r *Report) SetRelativePaths(relative bool) {
+	r.relativePaths = relative
+}
+
+// SetOmitLineContent leaves the line a secret was found on out of JSON findings. Together with
+// SetOmitMatchValue, findings stay trackable by file, line, pattern and fingerprint without any
+// part of the secret leaving the machine.
+func (r *Report) SetOmitLineContent(omit bool) {
+	r.omitLineContent = omit
+}
+
+// SetOmitMatchValue leaves the matched secret, even masked, out of JSON findings
+func (r *Report) SetOmitMatchValue(omit bool) {
+	r.omitMatch = omit
+}
+
+// withoutOmitted returns a copy of a JSON finding without the fields the report omits
+func (r *Report) withoutOmitted(match *MatchReport) *MatchReport {
+	stripped := *match
+	if r.omitLineContent {
+		stripped.LineContent = ""
+	}
+	if r.omitMatch {
+		stripped.Match = ""
+	}
+	return &stripped
+}
+
+// SetErrors lists the errors of a scan in the text and JSON reports. Files that failed to scan
+// may still hold secrets, so they are reported rather than only counted as skipped.
+func (r *Report) SetErrors(errs []error) {
+	r.scanErrors = errs
+}
+
+// SetCappedFiles notes in the text and JSON reports which files had matches dropped at the
+// per-file match limit, so a short list of findings is not mistaken for a complete one
+func (r *Report) SetCappedFiles(files []string, limit int) {
+	r.cappedFiles = files
+	r.matchLimit = limit
+}
+
+// SetFixed lists findings of a previous report that the scan no longer produces in the text and JSON reports
+func (r *Report) SetFixed(fixed []*MatchReport) {
+	r.fixed = fixed
+}
+
+// displayMatches returns matches with FilePath as the report shows it. The matches are copied rather
+// than changed, as callers still need the paths on disk.
+func (r *Report) displayMatches(matches []*scanner.Match) []*scanner.Match {
+	if !r.relativePaths {
+		return matches
+	}
+
+	shown := make([]*scanner.Match, len(matches))
+	for i, match := range matches {
+		copied := *match
+		copied.FilePath = r.displayPath(match)
+		shown[i] = &copied
+	}
+	return shown
+}
+
+// displayPath returns a finding's file relative to its scan root, or the absolute path with the home
+// directory shortened to ~ when the root is unknown
+func (r *Report) displayPath(match *scanner.Match) string {
+	switch {
+	case match.Commit != "":
+		// History findings are already named commit:path within the repository
+		return match.FilePath
+	case match.RelPath != "":
+		return match.RelPath
+	case !filepath.IsAbs(match.FilePath):
+		return match.FilePath
+	}
+
+	for _, root := range r.roots {
+		rel := utils.GetRelativePath(match.FilePath, root)
+		if rel != match.FilePath && rel != "." && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return utils.SanitizePath(match.FilePath)
+}
+
+// displayRoots returns the scan roots as the report shows them
+func (r *Report) displayRoots() []string {
+	if !r.relativePaths {
+		return r.roots
+	}
+
+	roots := make([]string, len(r.roots))
+	for i, root := range r.roots {
+		roots[i] = utils.SanitizePath(root)
+	}
+	return roots
+}
+
+// secret returns the matched secret as it should be displayed
+func (r *Report) secret(match *scanner.Match) string {
+	if r.showSecrets {
+		return match.MatchText
+	}
+	return utils.RedactSecret(match.MatchText)
+}
+
+// line returns a line from around a match with the secret masked, unless secrets are shown
+func (r *Report) line(match *scanner.Match, line string) string {
+	if r.showSecrets || match.MatchText == "" {
+		return line
+	}
+	return strings.ReplaceAll(line, match.MatchText, utils.RedactSecret(match.MatchText))
+}
+
+// matchReport converts a match to its JSON form, masked and without the fields the report omits
+func (r *Report) matchReport(match *scanner.Match) *MatchReport {
+	return r.withoutOmitted(&MatchReport{
+		FilePath:    match.FilePath,
+		LineNumber:  match.LineNumber,
+		Column:      match.Column,
+		EndColumn:   match.EndColumn,
+		PatternName: match.Pattern.Name,
+		Severity:    match.Pattern.Severity,
+		Match:       r.secret(match),
+		LineContent: r.line(match, match.LineContent),
+		Remediation: match.Pattern.Remediation,
+		CWE:         match.Pattern.CWE,
+		OWASP:       match.Pattern.OWASP,
+		Fingerprint: match.Fingerprint(),
+		Commit:      match.Commit,
+	})
+}
+
+// summarize counts matches by severity
+func summarize(matches []*scanner.Match) *Summary {
+	summary := &Summary{}
+	for _, match := range matches {
+		summary.add(match)
+	}
+	return summary
+}
+
+// add counts one match
+func (s *Summary) add(match *scanner.Match) {
+	s.TotalMatches++
+	switch match.Pattern.Severity {
+	case "high":
+		s.HighSeverity++
+	case "medium":
+		s.MediumSeverity++
+	case "low":
+		s.LowSeverity++
+	}
+}
+
+// sortMatches orders matches by file, line and pattern, or by pattern first when grouping by pattern
+func (r *Report) sortMatches(matches []*scanner.Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if r.groupBy == GroupByPattern && matches[i].Pattern.Name != matches[j].Pattern.Name {
+			return matches[i].Pattern.Name < matches[j].Pattern.Name
+		}
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		if matches[i].LineNumber != matches[j].LineNumber {
+			return matches[i].LineNumber < matches[j].LineNumber
+		}
+		return matches[i].Pattern.Name < matches[j].Pattern.Name
+	})
+}
+
+// colorEnabled reports whether output to w should be colored. Colors are only written to a terminal,
+// never when NO_COLOR is set, and never when color.NoColor was set by --no-color.
+func colorEnabled(w io.Writer) bool {
+	if color.NoColor {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// newColor returns a color that writes plain text when enabled is false
+func newColor(enabled bool, attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	if !enabled {
+		c.DisableColor()
+	}
+	return c
+}
+
+// color returns a color for this report's writer
+func (r *Report) color(attrs ...color.Attribute) *color.Color {
+	return newColor(r.colored, attrs...)
+}
+
+// severityStyle returns the color and icon used for a severity in the text and table reports
+func (r *Report) severityStyle(severity string) (*color.Color, string) {
+	switch severity {
+	case "high":
+		return r.color(color.FgRed), "🔴"
+	case "medium":
+		return r.color(color.FgYellow), "🟡"
+	default:
+		return r.color(color.FgGreen), "🟢"
 	}
 }
 
 // GenerateSecrets generates a report from secret scan results
 func (r *Report) GenerateSecrets(matches []*scanner.Match, filesScanned, filesSkipped int) error {
+	matches = r.displayMatches(matches)
 	switch r.format {
 	case "json":
 		return r.generateSecretsJSON(matches, filesScanned, filesSkipped)
 	case "table":
 		return r.generateSecretsTable(matches, filesScanned, filesSkipped)
+	case "sarif":
+		return r.generateSecretsSARIF(matches)
+	case "junit":
+		return r.generateSecretsJUnit(matches)
+	case "html":
+		return r.generateSecretsHTML(matches, filesScanned, filesSkipped)
+	case "markdown":
+		return r.generateSecretsMarkdown(matches, filesScanned, filesSkipped)
 	case "text", "":
 		return r.generateSecretsText(matches, filesScanned, filesSkipped)
 	default:
@@ -80,44 +438,104 @@ func (r *Report) GenerateSecrets(matches []*scanner.Match, filesScanned, filesSk
 
 // GenerateAnalysis generates a report from AI analysis
 func (r *Report) GenerateAnalysis(analysis *AnalysisReport) error {
+	if analysis.Timestamp == "" {
+		analysis.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	switch r.format {
+	case "markdown":
+		return r.generateAnalysisMarkdown(analysis)
+	case "json":
+		return r.generateAnalysisJSON(analysis)
+	case "html":
+		return r.generateAnalysisHTML(analysis)
+	}
+
 	fmt.Fprintf(r.writer, "────────────────────────────────────────────────────────\n\n")
 	fmt.Fprintf(r.writer, "📊 %s\n", analysis.Title)
 	fmt.Fprintf(r.writer, "🤖 Model: %s\n", analysis.Model)
-	fmt.Fprintf(r.writer, "⏱️  Duration: %s\n\n", analysis.Duration)
+	fmt.Fprintf(r.writer, "⏱️  Duration: %s\n", analysis.Duration)
+	fmt.Fprintf(r.writer, "🕒 Timestamp: %s\n\n", analysis.Timestamp)
 	fmt.Fprintf(r.writer, "────────────────────────────────────────────────────────\n\n")
 	fmt.Fprint(r.writer, analysis.Content)
 	fmt.Fprintf(r.writer, "\n\n────────────────────────────────────────────────────────\n")
+	if stats := analysis.statsLine(); stats != "" {
+		fmt.Fprintf(r.writer, "📈 %s\n", stats)
+	}
 	return nil
 }
 
-// generateSecretsJSON generates a JSON formatted report
-func (r *Report) generateSecretsJSON(matches []*scanner.Match, filesScanned, filesSkipped int) error {
-	reportMatches := make([]*MatchReport, len(matches))
-	summary := &Summary{}
+// GenerateAnalyses writes several AI analyses one after another. HTML puts them on a single page.
+func (r *Report) GenerateAnalyses(analyses []*AnalysisReport) error {
+	if len(analyses) == 0 {
+		return nil
+	}
 
-	for i, match := range matches {
-		reportMatches[i] = &MatchReport{
-			FilePath:    match.FilePath,
-			LineNumber:  match.LineNumber,
-			PatternName: match.Pattern.Name,
-			Severity:    match.Pattern.Severity,
-			Match:       match.MatchText,
-			LineContent: match.LineContent,
+	if r.format == "html" {
+		for _, analysis := range analyses {
+			if analysis.Timestamp == "" {
+				analysis.Timestamp = time.Now().UTC().Format(time.RFC3339)
+			}
 		}
+		return r.generateAnalysisHTML(analyses...)
+	}
 
-		summary.TotalMatches++
-		switch match.Pattern.Severity {
-		case "high":
-			summary.HighSeverity++
-		case "medium":
-			summary.MediumSeverity++
-		case "low":
-			summary.LowSeverity++
+	for i, analysis := range analyses {
+		if i > 0 {
+			fmt.Fprintf(r.writer, "\n")
+		}
+		if err := r.GenerateAnalysis(analysis); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// generateAnalysisJSON writes an AI analysis as a single JSON object
+func (r *Report) generateAnalysisJSON(analysis *AnalysisReport) error {
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonAnalysis(analysis))
+}
+
+// jsonAnalysis converts an AI analysis to its JSON output format
+func jsonAnalysis(analysis *AnalysisReport) *JSONAnalysisReport {
+	return &JSONAnalysisReport{
+		Title:        analysis.Title,
+		Model:        analysis.Model,
+		Duration:     analysis.Duration,
+		Timestamp:    analysis.Timestamp,
+		Content:      analysis.Content,
+		PromptTokens: analysis.PromptTokens,
+		EvalTokens:   analysis.EvalTokens,
+		TokensPerSec: analysis.TokensPerSec,
+	}
+}
+
+// generateSecretsJSON generates a JSON formatted report
+func (r *Report) generateSecretsJSON(matches []*scanner.Match, filesScanned, filesSkipped int) error {
+	// Sorted output is the same on every run, so reports can be diffed
+	r.sortMatches(matches)
+
+	// A summary-only report keeps an empty list so consumers see the same shape
+	listed := matches
+	if r.summaryOnly {
+		listed = nil
+	}
+
+	reportMatches := make([]*MatchReport, 0, len(listed))
+	for _, match := range listed {
+		reportMatches = append(reportMatches, r.matchReport(match))
+	}
 
 	report := &JSONReport{
-		Summary: summary,
+		Meta: &Meta{
+			Tool:      "goscout",
+			Version:   r.version,
+			ScannedAt: time.Now().UTC().Format(time.RFC3339),
+			Roots:     r.displayRoots(),
+		},
+		Summary: summarize(matches),
 		Matches: reportMatches,
 		Stats: &Stats{
 			FilesScanned: filesScanned,
@@ -125,6 +543,20 @@ func (r *Report) generateSecretsJSON(matches []*scanner.Match, filesScanned, fil
 		},
 	}
 
+	for _, err := range r.scanErrors {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	report.CappedFiles = r.cappedFiles
+	for _, fixed := range r.fixed {
+		report.Fixed = append(report.Fixed, r.withoutOmitted(fixed))
+	}
+	for _, analysis := range r.analyses {
+		if analysis.Timestamp == "" {
+			analysis.Timestamp = report.Meta.ScannedAt
+		}
+		report.Analysis = append(report.Analysis, jsonAnalysis(analysis))
+	}
+
 	encoder := json.NewEncoder(r.writer)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(report)
@@ -136,61 +568,103 @@ func (r *Report) generateSecretsText(matches []*scanner.Match, filesScanned, fil
 		fmt.Fprintf(r.writer, "✓ No secrets found!\n")
 		fmt.Fprintf(r.writer, "Files scanned: %d\n", filesScanned)
 		fmt.Fprintf(r.writer, "Files skipped: %d\n", filesSkipped)
+		r.writeErrors()
+		r.writeFixed()
 		return nil
 	}
 
-	// Sort matches by file and line number
-	sort.Slice(matches, func(i, j int) bool {
-		if matches[i].FilePath != matches[j].FilePath {
-			return matches[i].FilePath < matches[j].FilePath
-		}
-		return matches[i].LineNumber < matches[j].LineNumber
-	})
-
-	// Count severities
-	highCount := 0
-	mediumCount := 0
-	lowCount := 0
-
-	for _, match := range matches {
-		switch match.Pattern.Severity {
-		case "high":
-			highCount++
-		case "medium":
-			mediumCount++
-		case "low":
-			lowCount++
-		}
-	}
+	r.sortMatches(matches)
+	summary := summarize(matches)
 
 	// Header
-	redBold := color.New(color.FgRed, color.Bold)
-	yellowBold := color.New(color.FgYellow, color.Bold)
-	greenBold := color.New(color.FgGreen, color.Bold)
-	cyan := color.New(color.FgCyan)
+	redBold := r.color(color.FgRed, color.Bold)
+	yellowBold := r.color(color.FgYellow, color.Bold)
+	greenBold := r.color(color.FgGreen, color.Bold)
 
 	fmt.Fprintf(r.writer, "\n")
 	redBold.Fprintf(r.writer, "⚠️  Secrets Found!\n")
 	fmt.Fprintf(r.writer, "\n")
 
-	if highCount > 0 {
-		redBold.Fprintf(r.writer, "High Severity: %d\n", highCount)
+	if summary.HighSeverity > 0 {
+		redBold.Fprintf(r.writer, "High Severity: %d\n", summary.HighSeverity)
 	}
-	if mediumCount > 0 {
-		yellowBold.Fprintf(r.writer, "Medium Severity: %d\n", mediumCount)
+	if summary.MediumSeverity > 0 {
+		yellowBold.Fprintf(r.writer, "Medium Severity: %d\n", summary.MediumSeverity)
 	}
-	if lowCount > 0 {
-		greenBold.Fprintf(r.writer, "Low Severity: %d\n", lowCount)
+	if summary.LowSeverity > 0 {
+		greenBold.Fprintf(r.writer, "Low Severity: %d\n", summary.LowSeverity)
 	}
 
 	fmt.Fprintf(r.writer, "\n")
 	fmt.Fprintf(r.writer, "Files scanned: %d\n", filesScanned)
 	fmt.Fprintf(r.writer, "Files skipped: %d\n", filesSkipped)
+	r.writeErrors()
+	r.writeCappedFiles()
+	r.writeFixed()
+	if r.summaryOnly {
+		return nil
+	}
 	fmt.Fprintf(r.writer, "\n")
 
 	// Details
 	fmt.Fprintf(r.writer, "─────────────────────────────────────────────────────\n")
 
+	if r.groupBy == GroupByPattern {
+		r.writePatternGroups(matches)
+	} else {
+		r.writeFileGroups(matches)
+	}
+
+	fmt.Fprintf(r.writer, "─────────────────────────────────────────────────────\n")
+	return nil
+}
+
+// writeErrors prints the files that could not be scanned
+func (r *Report) writeErrors() {
+	if len(r.scanErrors) == 0 {
+		return
+	}
+
+	yellow := r.color(color.FgYellow)
+	fmt.Fprintf(r.writer, "\n")
+	yellow.Fprintf(r.writer, "Errors: %d\n", len(r.scanErrors))
+	for _, err := range r.scanErrors {
+		fmt.Fprintf(r.writer, "  ✗ %v\n", err)
+	}
+}
+
+// writeCappedFiles prints the files whose findings stop at the per-file match limit
+func (r *Report) writeCappedFiles() {
+	if len(r.cappedFiles) == 0 {
+		return
+	}
+
+	yellow := r.color(color.FgYellow)
+	fmt.Fprintf(r.writer, "\n")
+	yellow.Fprintf(r.writer, "Match limit of %d per file reached, later matches not reported:\n", r.matchLimit)
+	for _, file := range r.cappedFiles {
+		fmt.Fprintf(r.writer, "  ✂ %s\n", file)
+	}
+}
+
+// writeFixed prints the findings of the previous report that are gone
+func (r *Report) writeFixed() {
+	if len(r.fixed) == 0 {
+		return
+	}
+
+	green := r.color(color.FgGreen)
+	fmt.Fprintf(r.writer, "\n")
+	green.Fprintf(r.writer, "Fixed since previous report: %d\n", len(r.fixed))
+	for _, match := range r.fixed {
+		fmt.Fprintf(r.writer, "  ✓ %s:%d %s\n", match.FilePath, match.LineNumber, match.PatternName)
+	}
+}
+
+// writeFileGroups prints findings under a heading for each file
+func (r *Report) writeFileGroups(matches []*scanner.Match) {
+	cyan := r.color(color.FgCyan)
+
 	currentFile := ""
 	for _, match := range matches {
 		if match.FilePath != currentFile {
@@ -198,29 +672,79 @@ func (r *Report) generateSecretsText(matches []*scanner.Match, filesScanned, fil
 			cyan.Fprintf(r.writer, "\n📄 %s\n", match.FilePath)
 		}
 
-		var severityColor *color.Color
-		var severityIcon string
-		switch match.Pattern.Severity {
-		case "high":
-			severityColor = color.New(color.FgRed)
-			severityIcon = "🔴"
-		case "medium":
-			severityColor = color.New(color.FgYellow)
-			severityIcon = "🟡"
-		case "low":
-			severityColor = color.New(color.FgGreen)
-			severityIcon = "🟢"
-		}
-
+		severityColor, severityIcon := r.severityStyle(match.Pattern.Severity)
 		fmt.Fprintf(r.writer, "  Line %d: ", match.LineNumber)
 		severityColor.Fprintf(r.writer, "%s %s", severityIcon, match.Pattern.Name)
+		if match.Pattern.CWE != "" {
+			fmt.Fprintf(r.writer, " [%s]", match.Pattern.CWE)
+		}
 		fmt.Fprintf(r.writer, "\n")
-		fmt.Fprintf(r.writer, "    Content: %s\n", truncate(match.LineContent, 80))
-		fmt.Fprintf(r.writer, "    Match: %s\n\n", truncate(match.MatchText, 60))
+		r.writeMatchDetails(match)
 	}
+}
 
-	fmt.Fprintf(r.writer, "─────────────────────────────────────────────────────\n")
-	return nil
+// writePatternGroups prints findings under a heading for each pattern, listing where each occurrence was found
+func (r *Report) writePatternGroups(matches []*scanner.Match) {
+	cyan := r.color(color.FgCyan)
+
+	for start := 0; start < len(matches); {
+		pattern := matches[start].Pattern
+		end := start
+		for end < len(matches) && matches[end].Pattern.Name == pattern.Name {
+			end++
+		}
+
+		severityColor, severityIcon := r.severityStyle(pattern.Severity)
+		severityColor.Add(color.Bold).Fprintf(r.writer, "\n%s %s (%d)", severityIcon, pattern.Name, end-start)
+		if pattern.CWE != "" {
+			fmt.Fprintf(r.writer, " [%s]", pattern.CWE)
+		}
+		fmt.Fprintf(r.writer, "\n")
+		if pattern.Remediation != "" {
+			fmt.Fprintf(r.writer, "  Fix: %s\n", pattern.Remediation)
+		}
+
+		for _, match := range matches[start:end] {
+			cyan.Fprintf(r.writer, "  📄 %s:%d\n", match.FilePath, match.LineNumber)
+			r.writeMatchContent(match)
+			fmt.Fprintf(r.writer, "\n")
+		}
+
+		start = end
+	}
+}
+
+// writeMatchDetails prints the content, secret and remediation of a finding
+func (r *Report) writeMatchDetails(match *scanner.Match) {
+	r.writeMatchContent(match)
+	if match.Pattern.Remediation != "" {
+		fmt.Fprintf(r.writer, "    Fix: %s\n", match.Pattern.Remediation)
+	}
+	fmt.Fprintf(r.writer, "\n")
+}
+
+// writeMatchContent prints the matched line, or its context when enabled, and the secret
+func (r *Report) writeMatchContent(match *scanner.Match) {
+	if len(match.ContextBefore) > 0 || len(match.ContextAfter) > 0 {
+		r.writeContext(match)
+	} else {
+		fmt.Fprintf(r.writer, "    Content: %s\n", truncate(r.line(match, match.LineContent), 80))
+	}
+	fmt.Fprintf(r.writer, "    Match: %s\n", truncate(r.secret(match), 60))
+}
+
+// writeContext prints the lines around a match with their line numbers, highlighting the matched line
+func (r *Report) writeContext(match *scanner.Match) {
+	highlight := r.color(color.Bold)
+	first := match.LineNumber - len(match.ContextBefore)
+
+	for i, line := range match.ContextBefore {
+		fmt.Fprintf(r.writer, "      %5d | %s\n", first+i, truncate(r.line(match, line), 80))
+	}
+	highlight.Fprintf(r.writer, "    > %5d | %s\n", match.LineNumber, truncate(r.line(match, match.LineContent), 80))
+	for i, line := range match.ContextAfter {
+		fmt.Fprintf(r.writer, "      %5d | %s\n", match.LineNumber+1+i, truncate(r.line(match, line), 80))
+	}
 }
 
 // generateSecretsTable generates a table formatted report
@@ -230,12 +754,28 @@ func (r *Report) generateSecretsTable(matches []*scanner.Match, filesScanned, fi
 		return nil
 	}
 
-	sort.Slice(matches, func(i, j int) bool {
-		if matches[i].FilePath != matches[j].FilePath {
-			return matches[i].FilePath < matches[j].FilePath
-		}
-		return matches[i].LineNumber < matches[j].LineNumber
-	})
+	if r.summaryOnly {
+		summary := summarize(matches)
+		fmt.Fprintf(r.writer, "%-10s | %5s\n", "Severity", "Count")
+		fmt.Fprintf(r.writer, "%-10s-+-%5s\n", "──────────", "─────")
+		fmt.Fprintf(r.writer, "%-10s | %5d\n", "high", summary.HighSeverity)
+		fmt.Fprintf(r.writer, "%-10s | %5d\n", "medium", summary.MediumSeverity)
+		fmt.Fprintf(r.writer, "%-10s | %5d\n", "low", summary.LowSeverity)
+	} else {
+		r.writeTableRows(matches)
+	}
+
+	fmt.Fprintf(r.writer, "\n")
+	fmt.Fprintf(r.writer, "Total matches: %d\n", len(matches))
+	fmt.Fprintf(r.writer, "Files scanned: %d\n", filesScanned)
+	fmt.Fprintf(r.writer, "Files skipped: %d\n", filesSkipped)
+
+	return nil
+}
+
+// writeTableRows prints one table row per finding
+func (r *Report) writeTableRows(matches []*scanner.Match) {
+	r.sortMatches(matches)
 
 	fmt.Fprintf(r.writer, "%-50s | %-15s | %-10s | %-20s\n", "File", "Line", "Severity", "Pattern")
 	fmt.Fprintf(r.writer, "%-50s-+-%-15s-+-%-10s-+-%-20s\n",
@@ -245,90 +785,72 @@ func (r *Report) generateSecretsTable(matches []*scanner.Match, filesScanned, fi
 		"────────────────────")
 
 	for _, match := range matches {
-		filePath := match.FilePath
-		if len(filePath) > 50 {
-			filePath = "..." + filePath[len(filePath)-47:]
-		}
-		fmt.Fprintf(r.writer, "%-50s | %15d | %-10s | %-20s\n",
+		filePath := tablePath(match.FilePath, 50)
+		// Pad before coloring, since escape codes would otherwise count toward the column width
+		severityColor, _ := r.severityStyle(match.Pattern.Severity)
+		severity := severityColor.Sprint(fmt.Sprintf("%-10s", match.Pattern.Severity))
+
+		fmt.Fprintf(r.writer, "%-50s | %15d | %s | %-20s\n",
 			filePath,
 			match.LineNumber,
-			match.Pattern.Severity,
+			severity,
 			truncate(match.Pattern.Name, 20))
 	}
+}
 
-	fmt.Fprintf(r.writer, "\n")
-	fmt.Fprintf(r.writer, "Total matches: %d\n", len(matches))
-	fmt.Fprintf(r.writer, "Files scanned: %d\n", filesScanned)
-	fmt.Fprintf(r.writer, "Files skipped: %d\n", filesSkipped)
-
-	return nil
+// tablePath keeps the end of a path that is wider than a table column, where the file name is
+func tablePath(path string, width int) string {
+	if len(path) > width {
+		return "..." + path[len(path)-(width-3):]
+	}
+	return path
 }
 
-// truncate truncates a string to a maximum length
+// truncate shortens a string to a maximum length without splitting UTF-8 characters
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen-3] + "..."
+	return utils.TruncateString(s, maxLen)
 }
 
-// PrintPatterns prints available secret patterns
-func PrintPatterns() {
-	patterns := []struct {
+// PrintPatterns writes the given patterns grouped by severity, highest first, with their descriptions
+func PrintPatterns(w io.Writer, list []patterns.Pattern) {
+	groups := []struct {
 		severity string
-		items    []string
+		color    *color.Color
 	}{
-		{
-			severity: "HIGH SEVERITY",
-			items: []string{
-				"AWS Access Key",
-				"AWS Secret Access Key",
-				"AWS Session Token",
-				"Generic API Key",
-				"Database Connection String",
-				"GitHub Personal Access Token",
-				"GitHub OAuth Token",
-				"RSA Private Key",
-				"OpenSSH Private Key",
-				"PGP Private Key",
-				"EC Private Key",
-				"Google API Key",
-				"Firebase Key",
-				"Slack Token",
-				"JWT Token",
-				"Stripe API Key",
-				"Twilio API Key",
-				"Heroku API Key",
-				"Basic Auth Credentials",
-			},
-		},
-		{
-			severity: "MEDIUM SEVERITY",
-			items: []string{
-				"Password in Code",
-				"Secret Assignment",
-				"Mailchimp API Key",
-				"PagerDuty Token",
-			},
-		},
-		{
-			severity: "LOW SEVERITY",
-			items: []string{
-				"Private IP Address",
-			},
-		},
+		{"high", newColor(colorEnabled(w), color.FgRed, color.Bold)},
+		{"medium", newColor(colorEnabled(w), color.FgYellow, color.Bold)},
+		{"low", newColor(colorEnabled(w), color.FgGreen, color.Bold)},
 	}
 
-	fmt.Println("Available Secret Patterns:")
-	fmt.Println("─────────────────────────────────────────────────────────────")
-	fmt.Println("")
+	// Size the name column to the longest name so descriptions line up
+	width := 0
+	for _, p := range list {
+		if len(p.Name) > width {
+			width = len(p.Name)
+		}
+	}
+
+	fmt.Fprintln(w, "Available Secret Patterns:")
+	fmt.Fprintln(w, "─────────────────────────────────────────────────────────────")
+	fmt.Fprintln(w, "")
+
+	for _, group := range groups {
+		var members []patterns.Pattern
+		for _, p := range list {
+			if strings.EqualFold(p.Severity, group.severity) {
+				members = append(members, p)
+			}
+		}
+
+		if len(members) == 0 {
+			continue
+		}
 
-	for _, group := range patterns {
-		fmt.Printf("%s:\n", group.severity)
-		for _, item := range group.items {
-			fmt.Printf("  - %s\n", item)
+		group.color.Fprintf(w, "%s SEVERITY:\n", strings.ToUpper(group.severity))
+		for _, p := range members {
+			fmt.Fprintf(w, "  - %-*s  %s\n", width, p.Name, p.Description)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
@@ -0,0 +1,234 @@
+package report
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deadrootsec/goscout/pkg/patterns"
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "goscout"
+	toolURI      = "https://github.com/deadrootsec/goscout"
+)
+
+// SARIFReport is the root of a SARIF 2.1.0 log
+type SARIFReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun describes a single invocation of the tool
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+
+	// ColumnKind tells consumers that columns count Unicode code points rather than UTF-16 units
+	ColumnKind string `json:"columnKind,omitempty"`
+
+	Properties *SARIFRunProperties `json:"properties,omitempty"`
+}
+
+// SARIFRunProperties carries the AI analyses of a run's findings
+type SARIFRunProperties struct {
+	Analysis []*JSONAnalysisReport `json:"analysis"`
+}
+
+// SARIFTool wraps the driver component
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver names the tool and the rules it can report
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes one pattern
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	Help                 *SARIFMessage      `json:"help,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           *SARIFProperties   `json:"properties,omitempty"`
+}
+
+// SARIFProperties carries a rule's weakness classifications. The tags follow GitHub code scanning's
+// convention, e.g. "external/cwe/cwe-798".
+type SARIFProperties struct {
+	Tags  []string `json:"tags,omitempty"`
+	CWE   string   `json:"cwe,omitempty"`
+	OWASP string   `json:"owasp,omitempty"`
+}
+
+// SARIFConfiguration holds the default severity level of a rule
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFMessage is a plain text message
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a single finding
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFLocation points at where a finding was made
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and region within it
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           SARIFRegion           `json:"region"`
+}
+
+// SARIFArtifactLocation identifies a file by URI
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is the line range of a finding, narrowed to the secret's columns when they are known
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// generateSecretsSARIF generates a SARIF 2.1.0 formatted report
+func (r *Report) generateSecretsSARIF(matches []*scanner.Match) error {
+	driver := SARIFDriver{
+		Name:           toolName,
+		Version:        r.version,
+		InformationURI: toolURI,
+		Rules:          make([]SARIFRule, 0),
+	}
+
+	ruleIndex := make(map[string]int)
+	addRule := func(p *patterns.Pattern) int {
+		if index, ok := ruleIndex[p.ID()]; ok {
+			return index
+		}
+
+		rule := SARIFRule{
+			ID:                   p.ID(),
+			Name:                 p.Name,
+			ShortDescription:     SARIFMessage{Text: p.Description},
+			DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(p.Severity)},
+		}
+		if p.Remediation != "" {
+			rule.Help = &SARIFMessage{Text: p.Remediation}
+		}
+		if p.CWE != "" || p.OWASP != "" {
+			rule.Properties = sarifProperties(p)
+		}
+
+		driver.Rules = append(driver.Rules, rule)
+		ruleIndex[p.ID()] = len(driver.Rules) - 1
+		return len(driver.Rules) - 1
+	}
+
+	builtins := patterns.GetPatterns()
+	for i := range builtins {
+		addRule(&builtins[i])
+	}
+
+	results := make([]SARIFResult, 0, len(matches))
+	for _, match := range matches {
+		index := addRule(match.Pattern)
+		results = append(results, SARIFResult{
+			RuleID:    match.Pattern.ID(),
+			RuleIndex: index,
+			Level:     sarifLevel(match.Pattern.Severity),
+			Message:   SARIFMessage{Text: match.Pattern.Description + " detected"},
+			Locations: []SARIFLocation{
+				{
+					PhysicalLocation: SARIFPhysicalLocation{
+						ArtifactLocation: SARIFArtifactLocation{URI: sarifURI(match.FilePath)},
+						Region:           SARIFRegion{StartLine: match.LineNumber, StartColumn: match.Column, EndColumn: match.EndColumn},
+					},
+				},
+			},
+		})
+	}
+
+	report := &SARIFReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{
+			{
+				Tool:       SARIFTool{Driver: driver},
+				Results:    results,
+				ColumnKind: "unicodeCodePoints",
+			},
+		},
+	}
+
+	if len(r.analyses) > 0 {
+		props := &SARIFRunProperties{}
+		for _, analysis := range r.analyses {
+			if analysis.Timestamp == "" {
+				analysis.Timestamp = time.Now().UTC().Format(time.RFC3339)
+			}
+			props.Analysis = append(props.Analysis, jsonAnalysis(analysis))
+		}
+		report.Runs[0].Properties = props
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// sarifProperties classifies a rule by the pattern's CWE and OWASP category
+func sarifProperties(p *patterns.Pattern) *SARIFProperties {
+	props := &SARIFProperties{
+		Tags:  []string{"security"},
+		CWE:   p.CWE,
+		OWASP: p.OWASP,
+	}
+	if p.CWE != "" {
+		props.Tags = append(props.Tags, "external/cwe/"+strings.ToLower(p.CWE))
+	}
+	return props
+}
+
+// sarifLevel maps a pattern severity onto a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifURI converts a file path into a SARIF artifact URI
+func sarifURI(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !filepath.IsAbs(path) {
+		return slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// JSONStreamSummary is the last line of a streamed report, after every finding
+type JSONStreamSummary struct {
+	Summary     *Summary `json:"summary"`
+	Stats       *Stats   `json:"stats"`
+	Errors      []string `json:"errors,omitempty"`
+	CappedFiles []string `json:"capped_files,omitempty"`
+}
+
+// StreamReporter writes findings as JSON lines as soon as they are found, so a long scan neither
+// holds every finding in memory nor delays its output. Each finding is a MatchReport on a line of
+// its own, and Finish ends the stream with a JSONStreamSummary line.
+type StreamReporter struct {
+	report  *Report
+	encoder *json.Encoder
+	summary Summary
+	mu      sync.Mutex
+}
+
+// Stream returns a reporter writing JSON lines to the report's writer, masking and omitting fields
+// as the report is set up to
+func (r *Report) Stream() *StreamReporter {
+	return &StreamReporter{report: r, encoder: json.NewEncoder(r.writer)}
+}
+
+// ReportMatch writes a finding as one JSON line
+func (s *StreamReporter) ReportMatch(match *scanner.Match) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.summary.add(match)
+	return s.encoder.Encode(s.report.matchReport(s.report.displayMatches([]*scanner.Match{match})[0]))
+}
+
+// Summary returns the counts of the findings reported so far
+func (s *StreamReporter) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
+}
+
+// Finish writes the summary line with the severity counts, the file statistics and the errors and
+// capped files set on the report
+func (s *StreamReporter) Finish(filesScanned, filesSkipped int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := s.summary
+	line := &JSONStreamSummary{
+		Summary:     &summary,
+		Stats:       &Stats{FilesScanned: filesScanned, FilesSkipped: filesSkipped},
+		CappedFiles: s.report.cappedFiles,
+	}
+	for _, err := range s.report.scanErrors {
+		line.Errors = append(line.Errors, err.Error())
+	}
+	return s.encoder.Encode(line)
+}
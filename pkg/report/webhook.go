@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deadrootsec/goscout/pkg/scanner"
+)
+
+// WebhookTimeout bounds a webhook request, including reading the response
+const WebhookTimeout = 30 * time.Second
+
+// Webhook posts JSON secrets reports to an HTTP endpoint
+type Webhook struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+}
+
+// NewWebhook returns a webhook posting to url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:     url,
+		headers: make(http.Header),
+		client:  &http.Client{Timeout: WebhookTimeout},
+	}
+}
+
+// AddHeader adds a header, given as "Name: value", to every request, e.g. for authentication
+func (w *Webhook) AddHeader(header string) error {
+	name, value, found := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return fmt.Errorf("invalid webhook header %q: use \"Name: value\"", header)
+	}
+	w.headers.Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+// PostSecrets posts the secrets report r would write in the json format, masking and omitting fields
+// as r is set up to. A response other than 2xx is an error.
+func (w *Webhook) PostSecrets(r *Report, matches []*scanner.Match, filesScanned, filesSkipped int) error {
+	var body bytes.Buffer
+	jsonReport := *r
+	jsonReport.writer = &body
+	jsonReport.format = "json"
+	if err := jsonReport.GenerateSecrets(matches, filesScanned, filesSkipped); err != nil {
+		return fmt.Errorf("failed to generate webhook report: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, &body)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	for name, values := range w.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/utils"
+)
+
+const (
+	// archiveSeparator joins an archive path and the path of an entry inside it
+	archiveSeparator = "!"
+
+	// maxArchiveBytes caps the total uncompressed size read from one archive, guarding against zip bombs
+	maxArchiveBytes = 256 * 1024 * 1024
+
+	// maxArchiveEntries caps the number of entries read from one archive
+	maxArchiveEntries = 10000
+)
+
+// isArchive reports whether a file is an archive the scanner can descend into
+func isArchive(filePath string) bool {
+	name := strings.ToLower(filePath)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanArchive scans the text files inside a zip or tar archive. Findings are reported with a
+// FilePath of the form "archive.zip!config/app.env". Nested archives are not opened.
+func (s *Scanner) scanArchive(ctx context.Context, filePath string) ([]*Match, error) {
+	budget := &archiveBudget{remaining: maxArchiveBytes}
+
+	if strings.HasSuffix(strings.ToLower(filePath), ".zip") {
+		return s.scanZip(ctx, filePath, budget)
+	}
+	return s.scanTar(ctx, filePath, budget)
+}
+
+// scanZip scans the entries of a zip archive
+func (s *Scanner) scanZip(ctx context.Context, filePath string, budget *archiveBudget) ([]*Match, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer archive.Close()
+
+	if len(archive.File) > maxArchiveEntries {
+		return nil, fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+
+	var matches []*Match
+	for _, entry := range archive.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		r, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", entry.Name, err)
+		}
+		found, err := s.scanArchiveEntry(ctx, r, filePath, entry.Name, budget)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+	return matches, nil
+}
+
+// scanTar scans the regular files of a tar archive, gzip-compressed when named .tar.gz or .tgz
+func (s *Scanner) scanTar(ctx context.Context, filePath string, budget *archiveBudget) ([]*Match, error) {
+	file, err := s.open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Closing the file unblocks a read in progress when the scan is canceled
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	var r io.Reader = file
+	if name := strings.ToLower(filePath); strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var matches []*Match
+	archive := tar.NewReader(r)
+	for entries := 0; ; entries++ {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		if entries >= maxArchiveEntries {
+			return nil, fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		found, err := s.scanArchiveEntry(ctx, archive, filePath, header.Name, budget)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+	return matches, nil
+}
+
+// scanArchiveEntry scans one archive entry, skipping binary and oversized entries.
+// Entry sizes recorded in the archive are not trusted; reads are capped instead.
+func (s *Scanner) scanArchiveEntry(ctx context.Context, r io.Reader, archivePath, name string, budget *archiveBudget) ([]*Match, error) {
+	if isArchive(name) || s.isBinaryFile(name) {
+		return nil, nil
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, s.maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := budget.spend(len(content)); err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > s.maxFileSize {
+		return nil, nil
+	}
+
+	head := content
+	if len(head) > binarySniffSize {
+		head = head[:binarySniffSize]
+	}
+	if utils.IsBinaryContent(head) || s.isGenerated(head) {
+		return nil, nil
+	}
+
+	return s.scanReader(ctx, bytes.NewReader(content), archivePath+archiveSeparator+filepath.ToSlash(name))
+}
+
+// archiveBudget tracks how many uncompressed bytes may still be read from an archive
+type archiveBudget struct {
+	remaining int64
+}
+
+// spend deducts n bytes, failing once the archive has expanded past maxArchiveBytes
+func (b *archiveBudget) spend(n int) error {
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return fmt.Errorf("archive expands to more than %d bytes", maxArchiveBytes)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/patterns"
+)
+
+var (
+	// yamlKeyOnly matches a YAML key whose value starts on the next line, either plain or as a block scalar
+	yamlKeyOnly = regexp.MustCompile(`^(\s*)(?:-\s+)?["']?[\w.-]+["']?\s*:\s*([|>][-+]?)?\s*(?:#.*)?$`)
+
+	// yamlNested matches the first line of a nested mapping or sequence, which is not a wrapped value
+	yamlNested = regexp.MustCompile(`^(?:-(?:\s|$)|["']?[\w.-]+["']?\s*:(?:\s|$))`)
+)
+
+// logicalLine is an assignment reassembled from several physical lines
+type logicalLine struct {
+	lineNumber int // line of the key, where findings are reported
+	text       string
+}
+
+// logicalLines rejoins assignments wrapped across lines: shell lines ending in a backslash, and YAML keys
+// whose value is indented on the following lines. Only lines that span more than one physical line are returned.
+func logicalLines(lines []string) []logicalLine {
+	var logical []logicalLine
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasSuffix(line, `\`) {
+			var joined strings.Builder
+			start := i
+			for i < len(lines) && strings.HasSuffix(lines[i], `\`) {
+				joined.WriteString(strings.TrimSuffix(strings.TrimLeft(lines[i], " \t"), `\`))
+				i++
+			}
+			if i < len(lines) {
+				joined.WriteString(strings.TrimLeft(lines[i], " \t"))
+			}
+			logical = append(logical, logicalLine{lineNumber: start + 1, text: joined.String()})
+			continue
+		}
+
+		key := yamlKeyOnly.FindStringSubmatch(line)
+		if key == nil {
+			continue
+		}
+
+		// The value is every following line indented deeper than the key
+		indent := len(key[1])
+		var value []string
+		for j := i + 1; j < len(lines); j++ {
+			next := lines[j]
+			if strings.TrimSpace(next) == "" {
+				break
+			}
+			if len(next)-len(strings.TrimLeft(next, " \t")) <= indent {
+				break
+			}
+			value = append(value, strings.TrimSpace(next))
+		}
+		if len(value) == 0 || (key[2] == "" && yamlNested.MatchString(value[0])) {
+			continue
+		}
+
+		// Wrapped secrets are split without separators, so the pieces are joined back directly
+		head := strings.TrimRight(line[:strings.Index(line, ":")+1], " \t")
+		logical = append(logical, logicalLine{lineNumber: i + 1, text: head + " " + strings.Join(value, "")})
+	}
+
+	return logical
+}
+
+// continuationMatches matches single-line patterns against reassembled assignments, reporting each
+// finding on the line of its key. Secrets already found on that line are not reported twice.
+func (s *Scanner) continuationMatches(filePath string, lines []string, activePatterns []patterns.Pattern, matches []*Match) []*Match {
+	type key struct {
+		line    int
+		pattern string
+		secret  string
+	}
+	seen := make(map[key]bool)
+	for _, match := range matches {
+		seen[key{match.LineNumber, match.Pattern.Name, match.MatchText}] = true
+	}
+
+	for _, logical := range logicalLines(lines) {
+		prevLine := ""
+		if logical.lineNumber > 1 {
+			prevLine = lines[logical.lineNumber-2]
+		}
+
+		for i := range activePatterns {
+			pattern := &activePatterns[i]
+			if pattern.MultiLine {
+				continue
+			}
+
+			secret, _, _, keep := s.lineSecret(pattern, logical.text, prevLine)
+			if !keep || seen[key{logical.lineNumber, pattern.Name, secret}] {
+				continue
+			}
+			seen[key{logical.lineNumber, pattern.Name, secret}] = true
+
+			matches = append(matches, &Match{
+				FilePath:    filePath,
+				LineNumber:  logical.lineNumber,
+				MatchText:   secret,
+				Pattern:     pattern,
+				LineContent: logical.text,
+			})
+		}
+	}
+
+	return matches
+}
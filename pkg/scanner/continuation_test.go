@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScannerMultilineAssign(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		line     int
+		expected string
+	}{
+		{
+			name:     "yaml value on the next line",
+			content:  "service:\n  name: billing\n  api_key:\n    \"abcdefghij0123456789ABCD\"\n  region: eu\n",
+			line:     3,
+			expected: "abcdefghij0123456789ABCD",
+		},
+		{
+			name:     "yaml folded block scalar",
+			content:  "api_key: >-\n  abcdefghij0123\n  456789ABCD\nother: value\n",
+			line:     1,
+			expected: "abcdefghij0123456789ABCD",
+		},
+		{
+			name:     "shell backslash continuation",
+			content:  "#!/bin/sh\nexport API_KEY=\\\n  \"abcdefghij0123456789ABCD\"\nrun-service\n",
+			line:     2,
+			expected: "abcdefghij0123456789ABCD",
+		},
+	}
+
+	for _, tt := range tests {
+		scan := func(enabled bool) []*Match {
+			scanner := NewScanner()
+			scanner.SetMultilineAssign(enabled)
+			result, err := scanner.ScanReader(strings.NewReader(tt.content), StdinName)
+			if err != nil {
+				t.Fatalf("%s: ScanReader() returned error: %v", tt.name, err)
+			}
+			return result.Matches
+		}
+
+		if matches := scan(false); len(matches) != 0 {
+			t.Errorf("%s: expected no findings without --multiline-assign, got %d", tt.name, len(matches))
+		}
+
+		matches := scan(true)
+		if len(matches) != 1 {
+			t.Fatalf("%s: expected 1 finding, got %d", tt.name, len(matches))
+		}
+		if matches[0].LineNumber != tt.line || matches[0].MatchText != tt.expected {
+			t.Errorf("%s: expected %q on line %d, got %q on line %d",
+				tt.name, tt.expected, tt.line, matches[0].MatchText, matches[0].LineNumber)
+		}
+	}
+}
+
+func TestScannerMultilineAssignNoDuplicates(t *testing.T) {
+	// A secret already found on one line is not reported again from a continuation
+	content := "export API_KEY=\"abcdefghij0123456789ABCD\" \\\n  --verbose\n"
+
+	scanner := NewScanner()
+	scanner.SetMultilineAssign(true)
+	result, err := scanner.ScanReader(strings.NewReader(content), StdinName)
+	if err != nil {
+		t.Fatalf("ScanReader() returned error: %v", err)
+	}
+
+	if len(result.Matches) != 1 {
+		t.Errorf("expected 1 finding, got %d", len(result.Matches))
+	}
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/deadrootsec/goscout/pkg/utils"
+)
+
+// Reasons a file or directory is left out of a scan
+const (
+	SkipExcludedDir  = "excluded dir"
+	SkipExcludedFile = "excluded file"
+	SkipExtension    = "extension not included"
+	SkipIgnoreFile   = "listed in " + IgnoreFileName
+	SkipGitignore    = "git-ignored"
+	SkipSymlink      = "symlink"
+	SkipBinary       = "binary"
+	SkipTooLarge     = "too large"
+	SkipGenerated    = "generated"
+)
+
+// SkippedFile is a file or directory a scan would not read, and why
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// DryRunResult lists what a scan of the same paths would read, without matching anything
+type DryRunResult struct {
+	Files   []string
+	Skipped []SkippedFile
+	Errors  []error
+}
+
+// DryRun walks paths with all of the scanner's skip rules and reports which files would be scanned
+// and which would be skipped. Files are sniffed for binary content, as a scan does, but not matched.
+func (s *Scanner) DryRun(ctx context.Context, paths []string) (*DryRunResult, error) {
+	result := &ScanResult{Errors: make([]error, 0)}
+	dryRun := &DryRunResult{}
+
+	onSkip := func(filePath, reason string) {
+		dryRun.Skipped = append(dryRun.Skipped, SkippedFile{Path: filePath, Reason: reason})
+	}
+	files, _, err := s.collectPaths(ctx, paths, result, onSkip)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filePath := range files {
+		if s.archives && isArchive(filePath) {
+			dryRun.Files = append(dryRun.Files, filePath)
+			continue
+		}
+
+		reason, err := s.sniff(filePath)
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, fmt.Errorf("error scanning %s: %w", filePath, err))
+		case reason != "":
+			onSkip(filePath, reason)
+		default:
+			dryRun.Files = append(dryRun.Files, filePath)
+		}
+	}
+
+	sort.Strings(dryRun.Files)
+	sort.Slice(dryRun.Skipped, func(i, j int) bool {
+		return dryRun.Skipped[i].Path < dryRun.Skipped[j].Path
+	})
+	dryRun.Errors = result.Errors
+	return dryRun, nil
+}
+
+// sniff checks the start of a file as scanFile does, returning SkipBinary or SkipGenerated when the
+// file would be skipped for its content and "" when it would be scanned
+func (s *Scanner) sniff(filePath string) (string, error) {
+	file, err := s.open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head, err := bufio.NewReaderSize(file, binarySniffSize).Peek(binarySniffSize)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	switch {
+	case utils.IsBinaryContent(head):
+		return SkipBinary, nil
+	case s.isGenerated(head):
+		return SkipGenerated, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultGeneratedMarkers are the regular expressions that mark a file as generated when one
+// matches near its top, such as Go's "// Code generated by protoc-gen-go. DO NOT EDIT."
+var DefaultGeneratedMarkers = []string{
+	`Code generated .*DO NOT EDIT`,
+	`@generated`,
+	`(?i)auto-?generated.*do not (edit|modify)`,
+}
+
+// generatedHeaderLines is how many lines at the top of a file are searched for a generated-code marker
+const generatedHeaderLines = 10
+
+// errGeneratedContent marks a file skipped because it is generated code
+var errGeneratedContent = errors.New("generated content")
+
+// SetSkipGenerated skips files with a line matching one of markers, regular expressions, among their
+// first lines. Empty markers use DefaultGeneratedMarkers.
+func (s *Scanner) SetSkipGenerated(markers []string) error {
+	if len(markers) == 0 {
+		markers = DefaultGeneratedMarkers
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(markers))
+	for _, marker := range markers {
+		re, err := regexp.Compile(marker)
+		if err != nil {
+			return fmt.Errorf("invalid generated-code marker %q: %w", marker, err)
+		}
+		compiled = append(compiled, re)
+	}
+	s.generated = compiled
+	return nil
+}
+
+// isGenerated reports whether one of the first lines of head, the start of a file, has a generated-code marker
+func (s *Scanner) isGenerated(head []byte) bool {
+	if len(s.generated) == 0 {
+		return false
+	}
+
+	for i, rest := 0, head; i < generatedHeaderLines && len(rest) > 0; i++ {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		for _, marker := range s.generated {
+			if marker.Match(line) {
+				return true
+			}
+		}
+		rest = next
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/utils"
+)
+
+// ScanGitHistory scans every version of every file changed in the history of the repository
+// containing dir, oldest commit first. Findings carry the commit that introduced the content
+// and a FilePath of the form "<short hash>:<path>". Each distinct blob is scanned once.
+//
+// since limits the walk to commits after a revision (such as a tag or hash) or, when it is not
+// a revision, to commits newer than a date understood by git log --since. Empty scans everything.
+func (s *Scanner) ScanGitHistory(dir, since string) (*ScanResult, error) {
+	root, err := gitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"log", "--reverse", "--no-merges", "--format=%H"}
+	if since != "" {
+		if _, err := gitOutput(root, "rev-parse", "--verify", "--quiet", since+"^{commit}"); err == nil {
+			args = append(args, since+"..HEAD")
+		} else {
+			args = append(args, "--since="+since)
+		}
+	}
+
+	out, err := gitOutput(root, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	blobs, err := newBlobReader(root)
+	if err != nil {
+		return nil, err
+	}
+	defer blobs.close()
+
+	result := &ScanResult{
+		Matches: make([]*Match, 0),
+		Errors:  make([]error, 0),
+	}
+	defer s.startStats()(result)
+
+	scanned := make(map[string]bool)
+	for _, commit := range strings.Fields(string(out)) {
+		changes, err := changedBlobs(root, commit)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+
+		for _, change := range changes {
+			if scanned[change.blob] {
+				continue
+			}
+			scanned[change.blob] = true
+
+			if s.skipHistoryPath(root, change.path) {
+				result.FilesSkipped++
+				continue
+			}
+
+			content, err := blobs.read(change.blob)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s in %s: %w", change.path, commit[:7], err)
+			}
+
+			head := content
+			if len(head) > binarySniffSize {
+				head = head[:binarySniffSize]
+			}
+			if int64(len(content)) > s.maxFileSize || utils.IsBinaryContent(head) || s.isGenerated(head) {
+				result.FilesSkipped++
+				continue
+			}
+
+			matches, err := s.scanReader(context.Background(), bytes.NewReader(content), commit[:7]+":"+change.path)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("error scanning %s in %s: %w", change.path, commit[:7], err))
+				result.FilesSkipped++
+				continue
+			}
+
+			for _, match := range matches {
+				match.Commit = commit
+				match.RelPath = change.path
+			}
+			s.collect(result, matches)
+			result.FilesScanned++
+		}
+	}
+
+	return result, nil
+}
+
+// skipHistoryPath applies the exclusion and extension filters to a path from the repository history
+func (s *Scanner) skipHistoryPath(root, path string) bool {
+	for _, dir := range strings.Split(filepath.Dir(path), "/") {
+		if s.shouldSkipDir(dir) {
+			return true
+		}
+	}
+
+	fullPath := filepath.Join(root, filepath.FromSlash(path))
+	return s.excludeFiles[filepath.Base(fullPath)] ||
+		s.excludedByGlob(root, fullPath) ||
+		!s.included(fullPath) ||
+		s.isBinaryFile(fullPath)
+}
+
+// blobChange is a file added or modified by a commit
+type blobChange struct {
+	blob string
+	path string
+}
+
+// changedBlobs lists the files a commit added or modified, with the blob holding their new content
+func changedBlobs(root, commit string) ([]blobChange, error) {
+	out, err := gitOutput(root, "diff-tree", "--root", "--no-commit-id", "-r", "-z", "--diff-filter=AM", commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes in %s: %w", commit[:7], err)
+	}
+
+	// With -z each entry is ":<old mode> <new mode> <old blob> <new blob> <status>" NUL "<path>" NUL
+	var changes []blobChange
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(fields[i])
+		if len(meta) < 5 || meta[1] == "160000" {
+			// Submodule entries point at commits, not blobs
+			continue
+		}
+		changes = append(changes, blobChange{blob: meta[3], path: fields[i+1]})
+	}
+	return changes, nil
+}
+
+// blobReader reads blob contents through a single long-running git cat-file process
+type blobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// newBlobReader starts git cat-file --batch in the repository at root
+func newBlobReader(root string) (*blobReader, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = root
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+
+	return &blobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// read returns the content of a blob
+func (b *blobReader) read(blob string) ([]byte, error) {
+	if _, err := fmt.Fprintln(b.stdin, blob); err != nil {
+		return nil, err
+	}
+
+	// The header is "<blob> <type> <size>", or "<blob> missing"
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected cat-file header %q", strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected cat-file header %q", strings.TrimSpace(header))
+	}
+
+	// The content is followed by a newline
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(b.stdout, content); err != nil {
+		return nil, err
+	}
+	return content[:size], nil
+}
+
+// close stops the cat-file process
+func (b *blobReader) close() {
+	b.stdin.Close()
+	b.cmd.Wait()
+}
+
+// StagedFiles returns the absolute paths of files added, copied or modified in the git index
+// of the repository containing dir
+func StagedFiles(dir string) ([]string, error) {
+	root, err := gitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := gitOutput(root, "diff", "--cached", "--name-only", "--diff-filter=ACM", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+	}
+	return files, nil
+}
+
+// gitRoot returns the top-level directory of the git repository containing dir
+func gitRoot(dir string) (string, error) {
+	out, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository: %w", dir, err)
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// gitOutput runs git with args in dir and returns its standard output.
+// The error includes git's own message when it fails.
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/patterns"
+)
+
+// ignoreDirective matches an inline "goscout:ignore" comment with an optional list of pattern IDs
+var ignoreDirective = regexp.MustCompile(`goscout:ignore(?:[ \t]+([A-Za-z0-9_,-]+))?`)
+
+// ignoreRule is a single compiled line from a gitignore-style file
+type ignoreRule struct {
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList holds the rules of one gitignore-style file, relative to the directory it lives in
+type ignoreList struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// loadIgnoreFile reads a gitignore-style file whose patterns are relative to dir.
+// A missing file yields a nil list and no error.
+func loadIgnoreFile(dir, name string) (*ignoreList, error) {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseIgnore(dir, file)
+}
+
+// parseIgnore parses gitignore syntax: comments, blank lines, negation (!),
+// directory-only patterns (trailing /), anchored patterns and ** wildcards
+func parseIgnore(dir string, r io.Reader) (*ignoreList, error) {
+	list := &ignoreList{dir: dir}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+
+		// Skip blank lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		// A slash anywhere but the end anchors the pattern to the ignore file's directory
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		regex, err := globToRegexp(line, anchored)
+		if err != nil {
+			continue
+		}
+		rule.regex = regex
+		list.rules = append(list.rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// match reports whether any rule matched path and, if so, whether the last matching rule ignores it
+func (l *ignoreList) match(path string, isDir bool) (matched, ignored bool) {
+	relPath, err := filepath.Rel(l.dir, path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return false, false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for _, rule := range l.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.regex.MatchString(relPath) {
+			matched = true
+			ignored = !rule.negate
+		}
+	}
+
+	return matched, ignored
+}
+
+// globToRegexp converts a gitignore glob to a regular expression over slash-separated relative paths.
+// Unanchored patterns may match at any depth.
+func globToRegexp(glob string, anchored bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				if i+2 < len(glob) && glob[i+2] == '/' {
+					// "**/" matches zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					// A trailing "**" matches everything inside
+					sb.WriteString(".*")
+					i++
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				sb.WriteString(regexp.QuoteMeta(glob[i+1 : i+2]))
+				i++
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// ignoredByLists applies the ignore lists of path's ancestor directories up to root.
+// Lists closer to path take precedence, matching how nested .gitignore files behave.
+func ignoredByLists(lists map[string]*ignoreList, root, path string, isDir bool) bool {
+	var chain []*ignoreList
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if list := lists[dir]; list != nil {
+			chain = append(chain, list)
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(chain) - 1; i >= 0; i-- {
+		if matched, ign := chain[i].match(path, isDir); matched {
+			ignored = ign
+		}
+	}
+	return ignored
+}
+
+// ignoredInline reports whether a goscout:ignore directive on the line, or on the line just above it,
+// suppresses the pattern. A bare directive suppresses every pattern, a scoped one only the listed IDs.
+func ignoredInline(pattern *patterns.Pattern, line, prevLine string) bool {
+	for _, candidate := range []string{line, prevLine} {
+		directive := ignoreDirective.FindStringSubmatch(candidate)
+		if directive == nil {
+			continue
+		}
+
+		if directive[1] == "" {
+			return true
+		}
+
+		for _, id := range strings.Split(directive[1], ",") {
+			if strings.EqualFold(id, pattern.ID()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deadrootsec/goscout/pkg/utils"
+)
+
+// NDJSONRecord is one file in an NDJSON input: its path, as findings should report it, and its content
+type NDJSONRecord struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ScanNDJSON scans files handed over as newline-delimited JSON records instead of read from disk, so
+// a coordinator can collect contents elsewhere and have them scanned without filesystem access.
+// Findings are reported under each record's path. Records are skipped as files would be when they are
+// too large, binary or generated; a record without a path is an error, and malformed JSON ends the scan.
+func (s *Scanner) ScanNDJSON(r io.Reader) (*ScanResult, error) {
+	result := &ScanResult{
+		Matches: make([]*Match, 0),
+		Errors:  make([]error, 0),
+	}
+	defer s.startStats()(result)
+
+	decoder := json.NewDecoder(r)
+	for index := 1; ; index++ {
+		var record NDJSONRecord
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid NDJSON record %d: %w", index, err)
+		}
+
+		if strings.TrimSpace(record.Path) == "" {
+			result.Errors = append(result.Errors, fmt.Errorf("NDJSON record %d has no path", index))
+			result.FilesSkipped++
+			continue
+		}
+
+		content := []byte(record.Content)
+		head := content
+		if len(head) > binarySniffSize {
+			head = head[:binarySniffSize]
+		}
+		if int64(len(content)) > s.maxFileSize || utils.IsBinaryContent(head) || s.isGenerated(head) {
+			result.FilesSkipped++
+			continue
+		}
+
+		matches, err := s.scanReader(context.Background(), bytes.NewReader(content), record.Path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("error scanning %s: %w", record.Path, err))
+			result.FilesSkipped++
+			continue
+		}
+
+		// The path is all there is to go on, so it also keys the fingerprints
+		for _, match := range matches {
+			match.RelPath = utils.NormalizePathSeparators(record.Path)
+		}
+		s.collect(result, matches)
+		result.FilesScanned++
+
+		if s.failFast && len(matches) > 0 {
+			break
+		}
+	}
+
+	sortMatches(result.Matches)
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deadrootsec/goscout/pkg/patterns"
+)
+
+// minPrefilterLiteral is the shortest literal used to rule a line out. Shorter ones such as "=" occur
+// on almost every line, so a pattern that only has those is always run instead.
+const minPrefilterLiteral = 3
+
+// prefilter rules lines out before the patterns run. Most patterns can only match a line containing one
+// of a few literals, such as "AKIA" or "ghp_"; a line holding none of the literals of any pattern only
+// needs the patterns without literals. Literals are compared ignoring case, so a line the prefilter
+// rejects can never match a skipped pattern, and the matches are those of running every pattern.
+type prefilter struct {
+	literals  []string // lower-case ASCII literals of all skippable patterns
+	skippable []bool   // per pattern, whether it can only match a line containing one of literals
+}
+
+// newPrefilter extracts the literals every match of each pattern must contain
+func newPrefilter(list []patterns.Pattern) *prefilter {
+	f := &prefilter{skippable: make([]bool, len(list))}
+	seen := make(map[string]bool)
+	for i, pattern := range list {
+		if pattern.Regex == nil {
+			continue
+		}
+		re, err := syntax.Parse(pattern.Regex.String(), syntax.Perl)
+		if err != nil {
+			continue
+		}
+		literals := requiredLiterals(re.Simplify())
+		if literals == nil {
+			continue
+		}
+
+		f.skippable[i] = true
+		for _, literal := range literals {
+			if !seen[literal] {
+				seen[literal] = true
+				f.literals = append(f.literals, literal)
+			}
+		}
+	}
+	return f
+}
+
+// candidate reports whether line may match a skippable pattern. Lines with non-ASCII text are always
+// candidates, as case folding beyond ASCII does not line up with strings.ToLower.
+func (f *prefilter) candidate(line string) bool {
+	if f == nil || len(f.literals) == 0 {
+		return true
+	}
+	for i := 0; i < len(line); i++ {
+		if line[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(line)
+	for _, literal := range f.literals {
+		if strings.Contains(lower, literal) {
+			return true
+		}
+	}
+	return false
+}
+
+// skip reports whether the pattern at index i need not run on a line that is not a candidate
+func (f *prefilter) skip(i int) bool {
+	return f != nil && f.skippable[i]
+}
+
+// requiredLiterals returns lower-case literals such that every match of re contains at least one of
+// them, or nil when there is no such set of useful literals
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		literal := string(re.Rune)
+		for _, r := range re.Rune {
+			if r >= utf8.RuneSelf {
+				return nil
+			}
+		}
+		if len(literal) < minPrefilterLiteral {
+			return nil
+		}
+		return []string{strings.ToLower(literal)}
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return nil
+		}
+		return requiredLiterals(re.Sub[0])
+
+	case syntax.OpConcat:
+		// Any one part must match, so take the part whose shortest literal is longest
+		var best []string
+		for _, sub := range re.Sub {
+			if literals := requiredLiterals(sub); literals != nil && shortest(literals) > shortest(best) {
+				best = literals
+			}
+		}
+		return best
+
+	case syntax.OpAlternate:
+		var all []string
+		for _, sub := range re.Sub {
+			literals := requiredLiterals(sub)
+			if literals == nil {
+				return nil
+			}
+			all = append(all, literals...)
+		}
+		return all
+	}
+	return nil
+}
+
+// shortest returns the length of the shortest literal, 0 for none
+func shortest(literals []string) int {
+	n := 0
+	for i, literal := range literals {
+		if i == 0 || len(literal) < n {
+			n = len(literal)
+		}
+	}
+	return n
+}
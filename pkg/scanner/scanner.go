@@ -2,22 +2,78 @@ package scanner
 
 import (
 	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/deadrootsec/goscout/pkg/llm"
 	"github.com/deadrootsec/goscout/pkg/patterns"
+	"github.com/deadrootsec/goscout/pkg/utils"
 )
 
+// errBinaryContent marks a file skipped because its content is binary
+var errBinaryContent = errors.New("binary content")
+
 // Match represents a found secret match
 type Match struct {
-	FilePath    string
+	FilePath string
+
+	// RelPath is FilePath relative to the scanned root, with forward slashes. It is empty when
+	// the match did not come from a path scan.
+	RelPath string
+
+	// Commit is the commit that introduced the content, for matches from ScanGitHistory
+	Commit string
+
 	LineNumber  int
 	MatchText   string
 	Pattern     *patterns.Pattern
 	LineContent string
+
+	// Column is the 1-based column, in runes, where the secret starts on its line, and EndColumn the
+	// column just past its end. Both are 0 when unknown, and EndColumn is 0 for multi-line findings.
+	Column    int
+	EndColumn int
+
+	// ContextBefore and ContextAfter hold the lines around the match when context lines are enabled
+	ContextBefore []string
+	ContextAfter  []string
+
+	// capped marks the last match kept from a file whose further matches were dropped at the per-file limit
+	capped bool
+}
+
+// Fingerprint returns a stable identifier for the finding built from the relative file path,
+// pattern name and secret value. It leaves out the line number so it survives edits elsewhere
+// in the file, and the scan root so it is the same wherever the repository is checked out.
+func (m *Match) Fingerprint() string {
+	path := m.RelPath
+	if path == "" {
+		path = m.FilePath
+	}
+	sum := sha256.Sum256([]byte(utils.NormalizePathSeparators(path) + "\x00" + m.Pattern.Name + "\x00" + m.MatchText))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedactedLine returns the line content with the secret value masked
+func (m *Match) RedactedLine() string {
+	if m.MatchText == "" {
+		return m.LineContent
+	}
+	return strings.ReplaceAll(m.LineContent, m.MatchText, utils.RedactSecret(m.MatchText))
 }
 
 // AnalyzedMatch contains a match along with AI analysis
@@ -32,6 +88,12 @@ type ScanResult struct {
 	FilesScanned int
 	FilesSkipped int
 	Errors       []error
+
+	// CappedFiles are the files that reached the per-file match limit. Matches past the limit were not reported.
+	CappedFiles []string
+
+	// Stats is set when the scanner collects stats, see SetCollectStats
+	Stats *ScanStats
 }
 
 // ScanAndAnalyzeResult contains matches with AI analysis
@@ -41,19 +103,83 @@ type ScanAndAnalyzeResult struct {
 	FilesSkipped    int
 	Errors          []error
 	AnalysisErrors  []error
+	AnalysesSkipped int
 }
 
+// DefaultProximityKeywords are the keywords that vouch for a match of a pattern requiring one
+var DefaultProximityKeywords = []string{"key", "token", "secret", "password", "auth"}
+
+const (
+	// StdinName is the file name reported for content read from standard input
+	StdinName = "<stdin>"
+
+	// DefaultFileTimeout is how long a single file may take to scan before it is abandoned
+	DefaultFileTimeout = time.Minute
+
+	// DefaultEntropyThreshold is a sensible entropy threshold in bits per character for base64 tokens
+	DefaultEntropyThreshold = 4.5
+
+	// DefaultMaxLineLength is the longest line matched in one piece. Longer lines, such as minified
+	// code or embedded assets, are matched in pieces of this size.
+	DefaultMaxLineLength = 1024 * 1024
+
+	// DefaultKeywordProximity is how many characters on each side of a secret are searched for a keyword
+	DefaultKeywordProximity = 40
+
+	// binarySniffSize is how much of a file is inspected to tell binary content from text
+	binarySniffSize = 8 * 1024
+
+	// analysisContextLines is how many lines on each side of a match are sent for per-finding analysis
+	analysisContextLines = 3
+
+	// IgnoreFileName is the gitignore-style file in the scan root listing paths goscout skips
+	IgnoreFileName = ".goscoutignore"
+
+	// maxEntropyTokenLength skips longer tokens, which are embedded assets rather than credentials
+	maxEntropyTokenLength = 256
+)
+
 // Scanner performs the actual scanning
 type Scanner struct {
 	excludeDirs  map[string]bool
 	excludeFiles map[string]bool
+	excludeGlobs []*regexp.Regexp
+	includeExts  map[string]bool
+	archives     bool
+	verify       bool
+	proximity    int      // characters around a secret searched for keywords, 0 disables the check
+	keywords     []string // lower-case keywords for the proximity check
+	dedup        bool
+	multiAssign  bool             // rejoin assignments wrapped onto following lines before matching them
+	followLinks  bool             // walk into symlinked directories and scan symlinked files
+	generated    []*regexp.Regexp // generated-code markers searched for at the top of files, none to scan them
+	maxMatches   int              // matches reported per file before the rest of it is skipped, 0 for no limit
+	ignoreFile   *ignoreList
 	maxFileSize  int64
+	maxLineLen   int
+	fileTimeout  time.Duration
+	failFast     bool
+	concurrency  int
+	gitignore    bool
+	entropy      float64
+	redactForAI  bool
+	maxAnalyses  int
+	contextLines int
+	patterns     []patterns.Pattern
+	filter       *prefilter // rules lines out before patterns run; nil runs every pattern on every line
 	analyzer     *llm.Analyzer
+	stats        *statsCounter // nil unless collecting stats
+
+	// onMatch receives each file's matches as soon as the file is scanned, instead of ScanResult.Matches
+	onMatch func(*Match)
+
+	// open is used to read files; tests replace it to simulate slow or hung reads
+	open func(name string) (io.ReadCloser, error)
 }
 
 // NewScanner creates a new scanner instance
 func NewScanner() *Scanner {
-	return &Scanner{
+	s := &Scanner{
 		excludeDirs: map[string]bool{
 			".git":         true,
 			".hg":          true,
@@ -74,14 +200,27 @@ func NewScanner() *Scanner {
 		},
 		excludeFiles: map[string]bool{
 			".gitignore":        true,
+			IgnoreFileName:      true,
 			".dockerignore":     true,
 			"package-lock.json": true,
 			"yarn.lock":         true,
 			"go.sum":            true,
 		},
 		maxFileSize: 10 * 1024 * 1024, // 10MB
+		maxLineLen:  DefaultMaxLineLength,
+		fileTimeout: DefaultFileTimeout,
+		concurrency: runtime.NumCPU(),
+		patterns:    patterns.GetEnabledPatterns(),
 		analyzer:    nil,
+		open:        openFile,
 	}
+	s.filter = newPrefilter(s.patterns)
+	return s
+}
+
+// openFile opens a file from disk for scanning
+func openFile(name string) (io.ReadCloser, error) {
+	return os.Open(name)
 }
 
 // SetAnalyzer sets the LLM analyzer for AI-powered analysis
@@ -99,69 +238,553 @@ func (s *Scanner) AddExcludeFile(file string) {
 	s.excludeFiles[file] = true
 }
 
+// SetIncludeExtensions restricts scanning to files with the given extensions, such as ".go" or "py".
+// An empty list scans every file.
+func (s *Scanner) SetIncludeExtensions(exts []string) {
+	s.includeExts = make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		s.includeExts[ext] = true
+	}
+}
+
+// SetScanArchives makes the scanner descend into .zip, .tar, .tar.gz and .tgz archives instead of skipping them
+func (s *Scanner) SetScanArchives(enabled bool) {
+	s.archives = enabled
+}
+
+// SetVerify drops matches that fail their pattern's structural validation, such as AWS key IDs
+// that cannot belong to a real account
+func (s *Scanner) SetVerify(enabled bool) {
+	s.verify = enabled
+}
+
+// SetKeywordProximity keeps matches of patterns that require a keyword only when one of keywords
+// appears within distance characters of the secret. A distance of 0 disables the check.
+func (s *Scanner) SetKeywordProximity(distance int, keywords []string) {
+	s.proximity = distance
+	s.keywords = nil
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			s.keywords = append(s.keywords, keyword)
+		}
+	}
+}
+
+// SetMultilineAssign also matches assignments whose value continues on the following lines, such as
+// YAML keys with the value on the next line and shell lines ending in a backslash
+func (s *Scanner) SetMultilineAssign(enabled bool) {
+	s.multiAssign = enabled
+}
+
+// SetMaxMatchesPerFile reports at most n matches per file, so a generated or minified file cannot
+// flood the report. Once the limit is reached no more patterns run on the file's lines. Capped files
+// still count as scanned and are listed in ScanResult.CappedFiles. Zero removes the limit.
+func (s *Scanner) SetMaxMatchesPerFile(n int) {
+	s.maxMatches = n
+}
+
+// SetFollowSymlinks walks into symlinked directories and scans symlinked files. Directories reached
+// again through a link are skipped, so link cycles end. By default symlinks are skipped.
+func (s *Scanner) SetFollowSymlinks(follow bool) {
+	s.followLinks = follow
+}
+
+// SetDedup collapses matches of several patterns on the same line with the same secret value into one,
+// keeping the highest-severity pattern
+func (s *Scanner) SetDedup(enabled bool) {
+	s.dedup = enabled
+}
+
+// SetIgnoreFile reads gitignore-style rules from path and applies them, relative to each scan root,
+// instead of the root's own .goscoutignore
+func (s *Scanner) SetIgnoreFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer file.Close()
+
+	list, err := parseIgnore("", file)
+	if err != nil {
+		return fmt.Errorf("failed to read ignore file %s: %w", path, err)
+	}
+
+	s.ignoreFile = list
+	return nil
+}
+
+// AddExcludePattern excludes files and directories matching a glob. A glob containing a slash
+// matches the path relative to the scan root, one without matches the base name at any depth.
+// "**" matches any number of directories.
+func (s *Scanner) AddExcludePattern(glob string) error {
+	glob = filepath.ToSlash(glob)
+	regex, err := globToRegexp(strings.TrimPrefix(glob, "/"), strings.Contains(glob, "/"))
+	if err != nil {
+		return fmt.Errorf("invalid exclude pattern %q: %w", glob, err)
+	}
+
+	s.excludeGlobs = append(s.excludeGlobs, regex)
+	return nil
+}
+
 // SetMaxFileSize sets the maximum file size to scan
 func (s *Scanner) SetMaxFileSize(size int64) {
 	s.maxFileSize = size
 }
 
+// SetMaxLineLength sets the longest line matched in one piece. A longer line is matched in pieces
+// of n bytes, all reported on its line number, so a secret split across two pieces can be missed.
+func (s *Scanner) SetMaxLineLength(n int) {
+	if n > 0 {
+		s.maxLineLen = n
+	}
+}
+
+// SetFileTimeout sets how long a single file may take to scan.
+// A file that exceeds it is abandoned and counted as skipped. Zero disables the limit.
+func (s *Scanner) SetFileTimeout(timeout time.Duration) {
+	s.fileTimeout = timeout
+}
+
+// SetConcurrency sets the number of files scanned in parallel
+func (s *Scanner) SetConcurrency(n int) {
+	if n > 0 {
+		s.concurrency = n
+	}
+}
+
+// SetRespectGitignore skips paths matched by .gitignore files found during the walk
+func (s *Scanner) SetRespectGitignore(respect bool) {
+	s.gitignore = respect
+}
+
+// SetEntropyThreshold flags tokens whose Shannon entropy reaches threshold bits per character.
+// Hex tokens are held to a proportionally lower bar since their alphabet is smaller. Zero disables it.
+func (s *Scanner) SetEntropyThreshold(threshold float64) {
+	s.entropy = threshold
+}
+
+// SetFailFast stops the scan as soon as the first file with a match has been scanned
+func (s *Scanner) SetFailFast(failFast bool) {
+	s.failFast = failFast
+}
+
+// SetRedactBeforeAI masks secret values in the content sent to the analyzer
+func (s *Scanner) SetRedactBeforeAI(redact bool) {
+	s.redactForAI = redact
+}
+
+// SetPatterns replaces the set of patterns the scanner matches against
+func (s *Scanner) SetPatterns(p []patterns.Pattern) {
+	s.patterns = p
+	s.filter = newPrefilter(p)
+}
+
+// SetMatchHandler hands every match to handler as soon as its file is scanned, instead of collecting
+// it in ScanResult.Matches, so a long scan can report findings without holding them all in memory.
+// handler is never called concurrently. Nil collects the matches again.
+func (s *Scanner) SetMatchHandler(handler func(*Match)) {
+	s.onMatch = handler
+}
+
+// collect hands the matches of one scanned file to the match handler, or adds them to result
+func (s *Scanner) collect(result *ScanResult, matches []*Match) {
+	result.CappedFiles = append(result.CappedFiles, s.cappedFiles(matches)...)
+	if s.onMatch == nil {
+		result.Matches = append(result.Matches, matches...)
+		return
+	}
+	for _, match := range matches {
+		s.onMatch(match)
+	}
+}
+
+// SetContextLines sets how many lines before and after each match are kept with it
+func (s *Scanner) SetContextLines(n int) {
+	if n >= 0 {
+		s.contextLines = n
+	}
+}
+
+// SetMaxAnalyses caps how many matches AnalyzeMatches sends to the analyzer; 0 means no cap
+func (s *Scanner) SetMaxAnalyses(n int) {
+	if n >= 0 {
+		s.maxAnalyses = n
+	}
+}
+
 // ScanPath scans a directory for secrets
 func (s *Scanner) ScanPath(path string) (*ScanResult, error) {
+	return s.ScanPathContext(context.Background(), path)
+}
+
+// ScanReader scans content that does not live on disk, such as standard input, reporting matches
+// under the given display name
+func (s *Scanner) ScanReader(r io.Reader, name string) (*ScanResult, error) {
+	finishStats := s.startStats()
+	matches, err := s.scanReader(context.Background(), r, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", name, err)
+	}
+
+	result := &ScanResult{
+		Matches:      make([]*Match, 0),
+		FilesScanned: 1,
+		Errors:       make([]error, 0),
+	}
+	s.collect(result, matches)
+	finishStats(result)
+	return result, nil
+}
+
+// ScanPathContext scans a directory for secrets until ctx is canceled.
+// On cancellation the matches gathered so far are returned together with the context error.
+func (s *Scanner) ScanPathContext(ctx context.Context, path string) (*ScanResult, error) {
+	return s.ScanPathsContext(ctx, []string{path})
+}
+
+// ScanPaths scans several files or directories and merges the findings into one result
+func (s *Scanner) ScanPaths(paths []string) (*ScanResult, error) {
+	return s.ScanPathsContext(context.Background(), paths)
+}
+
+// ScanPathsContext scans several files or directories until ctx is canceled.
+// A file reachable through more than one path is scanned only once.
+func (s *Scanner) ScanPathsContext(ctx context.Context, paths []string) (*ScanResult, error) {
 	result := &ScanResult{
 		Matches: make([]*Match, 0),
 		Errors:  make([]error, 0),
 	}
+	defer s.startStats()(result)
+
+	files, relPaths, err := s.collectPaths(ctx, paths, result, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, err
+		}
+		return nil, err
+	}
+
+	s.scanFiles(ctx, files, relPaths, result)
+	sortMatches(result.Matches)
+	sort.Strings(result.CappedFiles)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return result, ctxErr
+	}
+
+	return result, nil
+}
+
+// collectPaths collects the files to scan under each path, dropping files reachable through more than
+// one path. It also returns each file's path relative to the root it was found under.
+func (s *Scanner) collectPaths(ctx context.Context, paths []string, result *ScanResult, onSkip func(filePath, reason string)) ([]string, map[string]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	relPaths := make(map[string]string)
+	for _, path := range paths {
+		found, err := s.collectFiles(ctx, path, result, onSkip)
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
 
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("error accessing %s: %w", filePath, err))
-			return nil
+			return nil, nil, err
 		}
 
-		// Skip directories
-		if info.IsDir() {
-			if s.shouldSkipDir(info.Name()) {
-				return filepath.SkipDir
+		for _, filePath := range found {
+			key := filePath
+			if absPath, err := filepath.Abs(filePath); err == nil {
+				key = absPath
 			}
-			return nil
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			files = append(files, filePath)
+			relPaths[filePath] = relativePath(path, filePath)
 		}
+	}
+	return files, relPaths, nil
+}
+
+// rootIgnoreList returns the --ignore-file rules anchored at root, or the .goscoutignore
+// file in root when no override is set
+func (s *Scanner) rootIgnoreList(root string) (*ignoreList, error) {
+	dir := root
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		dir = filepath.Dir(root)
+	}
+
+	if s.ignoreFile != nil {
+		return &ignoreList{dir: dir, rules: s.ignoreFile.rules}, nil
+	}
+
+	list, err := loadIgnoreFile(dir, IgnoreFileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s in %s: %w", IgnoreFileName, dir, err)
+	}
+	return list, nil
+}
+
+// relativePath returns filePath relative to the root it was found under, with forward slashes.
+// A file given directly as the root is identified by its name.
+func relativePath(root, filePath string) string {
+	relPath, err := filepath.Rel(filepath.Clean(root), filepath.Clean(filePath))
+	if err != nil || relPath == "." {
+		relPath = filepath.Base(filePath)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// collectFiles walks path and returns the files to scan, counting skipped files and
+// recording access errors in result. onSkip, when set, is told about every skipped file and directory.
+func (s *Scanner) collectFiles(ctx context.Context, path string, result *ScanResult, onSkip func(filePath, reason string)) ([]string, error) {
+	root := filepath.Clean(path)
+	gitignores := make(map[string]*ignoreList)
+
+	ignores, ignoreErr := s.rootIgnoreList(root)
+	if ignoreErr != nil {
+		result.Errors = append(result.Errors, ignoreErr)
+	}
+
+	// skip counts a skipped file; skipDir reports a skipped directory, which is not counted
+	skip := func(filePath, reason string) {
+		result.FilesSkipped++
+		if onSkip != nil {
+			onSkip(filePath, reason)
+		}
+	}
+	skipDir := func(dirPath, reason string) error {
+		if onSkip != nil {
+			onSkip(dirPath, reason)
+		}
+		return filepath.SkipDir
+	}
 
+	// keep applies the file filters, counting the files it rejects as skipped
+	keep := func(filePath string, info os.FileInfo) bool {
 		// Skip excluded files
-		if s.excludeFiles[info.Name()] {
-			result.FilesSkipped++
-			return nil
+		if s.excludeFiles[info.Name()] || s.excludedByGlob(root, filePath) {
+			skip(filePath, SkipExcludedFile)
+			return false
+		}
+		if !s.included(filePath) {
+			skip(filePath, SkipExtension)
+			return false
+		}
+
+		// Skip files listed in .goscoutignore
+		if ignores != nil {
+			if _, ignored := ignores.match(filepath.Clean(filePath), false); ignored {
+				skip(filePath, SkipIgnoreFile)
+				return false
+			}
+		}
+
+		// Skip git-ignored files
+		if s.gitignore && ignoredByLists(gitignores, root, filePath, false) {
+			skip(filePath, SkipGitignore)
+			return false
+		}
+
+		// Archives are opened instead of skipped when enabled; their entries have their own size limits
+		if s.archives && isArchive(filePath) {
+			return true
 		}
 
 		// Skip binary files
 		if s.isBinaryFile(filePath) {
-			result.FilesSkipped++
-			return nil
+			skip(filePath, SkipBinary)
+			return false
 		}
 
 		// Skip large files
 		if info.Size() > s.maxFileSize {
-			result.FilesSkipped++
-			return nil
+			skip(filePath, SkipTooLarge)
+			return false
+		}
+
+		return true
+	}
+
+	// A file given directly is scanned on its own, after the same checks as a file found by walking
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan %s: %w", path, err)
+	}
+	if info.Mode().IsRegular() {
+		if keep(path, info) {
+			return []string{path}, nil
+		}
+		return nil, nil
+	}
+
+	var (
+		files   []string
+		visited = make(map[string]bool) // real paths of the directories walked, when following symlinks
+		visit   fs.WalkDirFunc
+	)
+	visit = func(filePath string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
 		}
 
-		// Scan the file
-		matches, err := s.scanFile(filePath)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("error scanning %s: %w", filePath, err))
-			result.FilesSkipped++
+			result.Errors = append(result.Errors, fmt.Errorf("error accessing %s: %w", filePath, err))
 			return nil
 		}
 
-		result.Matches = append(result.Matches, matches...)
-		result.FilesScanned++
+		// Symlinks are skipped unless following them; a linked directory is walked as part of the tree
+		if d.Type()&fs.ModeSymlink != 0 {
+			if !s.followLinks {
+				skip(filePath, SkipSymlink)
+				return nil
+			}
+			target, err := os.Stat(filePath)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("error following %s: %w", filePath, err))
+				return nil
+			}
+			if target.IsDir() {
+				// The trailing separator makes WalkDir resolve the link instead of reporting it again
+				return filepath.WalkDir(filePath+string(filepath.Separator), visit)
+			}
+			if keep(filePath, target) {
+				files = append(files, filePath)
+			}
+			return nil
+		}
 
+		// Skip directories
+		if d.IsDir() {
+			filePath = filepath.Clean(filePath)
+			if s.shouldSkipDir(d.Name()) || (filePath != root && s.excludedByGlob(root, filePath)) {
+				return skipDir(filePath, SkipExcludedDir)
+			}
+			if ignores != nil && filePath != root {
+				if _, ignored := ignores.match(filePath, true); ignored {
+					return skipDir(filePath, SkipIgnoreFile)
+				}
+			}
+			if s.followLinks {
+				// A link back to a directory already walked would loop forever
+				if real, err := filepath.EvalSymlinks(filePath); err == nil {
+					if visited[real] {
+						return filepath.SkipDir
+					}
+					visited[real] = true
+				}
+			}
+			if s.gitignore {
+				if ignoredByLists(gitignores, root, filePath, true) {
+					return skipDir(filePath, SkipGitignore)
+				}
+				list, err := loadIgnoreFile(filePath, ".gitignore")
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Errorf("error reading .gitignore in %s: %w", filePath, err))
+				} else if list != nil {
+					gitignores[filePath] = list
+				}
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("error accessing %s: %w", filePath, err))
+			return nil
+		}
+		if keep(filePath, info) {
+			files = append(files, filePath)
+		}
 		return nil
-	})
+	}
 
-	if err != nil {
-		return nil, err
+	err = filepath.WalkDir(path, visit)
+	return files, err
+}
+
+// scanFiles scans files with a bounded pool of workers and merges their findings into result.
+// relPaths maps each file to its path relative to the scan root it was found under.
+func (s *Scanner) scanFiles(ctx context.Context, files []string, relPaths map[string]string, result *ScanResult) {
+	// stopCtx is canceled by fail-fast so queued files are dropped
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		stopped bool
+	)
+
+	tasks := make(chan string)
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range tasks {
+				matches, err := s.scanFileWithTimeout(stopCtx, filePath)
+
+				mu.Lock()
+				switch {
+				case stopped:
+					// Fail-fast already triggered, late results are discarded
+				case errors.Is(err, errBinaryContent), errors.Is(err, errGeneratedContent):
+					result.FilesSkipped++
+				case err != nil:
+					if ctx.Err() == nil {
+						result.Errors = append(result.Errors, fmt.Errorf("error scanning %s: %w", filePath, err))
+					}
+					result.FilesSkipped++
+				default:
+					for _, match := range matches {
+						if relPath, ok := relPaths[match.FilePath]; ok {
+							match.RelPath = relPath
+						} else if archive, entry, found := strings.Cut(match.FilePath, archiveSeparator); found {
+							match.RelPath = relPaths[archive] + archiveSeparator + entry
+						}
+					}
+					s.collect(result, matches)
+					result.FilesScanned++
+					if s.failFast && len(matches) > 0 {
+						stopped = true
+						stop()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	return result, nil
+feed:
+	for _, filePath := range files {
+		select {
+		case tasks <- filePath:
+		case <-stopCtx.Done():
+			break feed
+		}
+	}
+	close(tasks)
+	wg.Wait()
+}
+
+// sortMatches orders matches by file path and line number so output is stable between runs
+func sortMatches(matches []*Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].FilePath != matches[j].FilePath {
+			return matches[i].FilePath < matches[j].FilePath
+		}
+		return matches[i].LineNumber < matches[j].LineNumber
+	})
 }
 
 // ScanPathWithAnalysis scans a directory for secrets and analyzes them with AI
@@ -176,17 +799,29 @@ func (s *Scanner) ScanPathWithAnalysis(path string) (*ScanAndAnalyzeResult, erro
 		return nil, err
 	}
 
-	// Prepare result
+	// Analyze each match with the LLM
+	result := s.AnalyzeMatches(scanResult.Matches)
+	result.FilesScanned = scanResult.FilesScanned
+	result.FilesSkipped = scanResult.FilesSkipped
+	result.Errors = scanResult.Errors
+
+	return result, nil
+}
+
+// AnalyzeMatches analyzes each match individually together with the code around it.
+// When a cap is set with SetMaxAnalyses, matches beyond it are counted in AnalysesSkipped.
+func (s *Scanner) AnalyzeMatches(matches []*Match) *ScanAndAnalyzeResult {
 	result := &ScanAndAnalyzeResult{
 		AnalyzedMatches: make([]*AnalyzedMatch, 0),
-		FilesScanned:    scanResult.FilesScanned,
-		FilesSkipped:    scanResult.FilesSkipped,
-		Errors:          scanResult.Errors,
 		AnalysisErrors:  make([]error, 0),
 	}
 
-	// Analyze each match with the LLM
-	for _, match := range scanResult.Matches {
+	for i, match := range matches {
+		if s.maxAnalyses > 0 && i >= s.maxAnalyses {
+			result.AnalysesSkipped = len(matches) - i
+			break
+		}
+
 		analyzedMatch, err := s.analyzeMatch(match)
 		if err != nil {
 			result.AnalysisErrors = append(result.AnalysisErrors, fmt.Errorf("failed to analyze match in %s:%d: %w", match.FilePath, match.LineNumber, err))
@@ -195,13 +830,21 @@ func (s *Scanner) ScanPathWithAnalysis(path string) (*ScanAndAnalyzeResult, erro
 		result.AnalyzedMatches = append(result.AnalyzedMatches, analyzedMatch)
 	}
 
-	return result, nil
+	return result
 }
 
-// analyzeMatch sends a secret match to the analyzer for detailed analysis
+// analyzeMatch sends a secret match and its surrounding code to the analyzer for detailed analysis
 func (s *Scanner) analyzeMatch(match *Match) (*AnalyzedMatch, error) {
-	// Create a prompt that includes the matched secret and context
-	prompt := llm.SecretsAnalysisPrompt(match.LineContent)
+	code := s.matchContext(match)
+	prompt, err := s.analyzer.Prompt(llm.PromptData{
+		Content:    code,
+		File:       match.FilePath,
+		Line:       match.LineNumber,
+		SecretType: match.Pattern.Name,
+	}, llm.ContextualSecurityPrompt(match.FilePath, match.LineNumber, match.Pattern.Name, code))
+	if err != nil {
+		return nil, err
+	}
 
 	// Query the analyzer
 	analysis, err := s.analyzer.Query(prompt)
@@ -215,50 +858,551 @@ func (s *Scanner) analyzeMatch(match *Match) (*AnalyzedMatch, error) {
 	}, nil
 }
 
-// scanFile scans a single file for secrets
-func (s *Scanner) scanFile(filePath string) ([]*Match, error) {
-	var matches []*Match
+// matchContext returns the lines around a match, numbered and with the matched line marked.
+// It falls back to the matched line alone when the file can no longer be read.
+func (s *Scanner) matchContext(match *Match) string {
+	lines := []string{match.LineContent}
+	first := match.LineNumber
+
+	if file, err := s.open(match.FilePath); err == nil {
+		var fileLines []string
+		lineScanner := bufio.NewScanner(file)
+		for lineScanner.Scan() {
+			fileLines = append(fileLines, lineScanner.Text())
+			if len(fileLines) >= match.LineNumber+analysisContextLines {
+				break
+			}
+		}
+		file.Close()
+
+		if match.LineNumber <= len(fileLines) {
+			first = match.LineNumber - analysisContextLines
+			if first < 1 {
+				first = 1
+			}
+			lines = fileLines[first-1:]
+		}
+	}
+
+	var sb strings.Builder
+	for i, line := range lines {
+		lineNumber := first + i
+		if s.redactForAI && match.MatchText != "" {
+			line = strings.ReplaceAll(line, match.MatchText, utils.RedactSecret(match.MatchText))
+		}
+
+		marker := " "
+		if lineNumber == match.LineNumber {
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "%s %4d | %s\n", marker, lineNumber, line)
+	}
+	return sb.String()
+}
+
+// scanFileWithTimeout scans a single file, giving up once the file timeout elapses or ctx is canceled.
+// The abandoned scan is canceled too: its file is closed and it stops at the next line.
+func (s *Scanner) scanFileWithTimeout(ctx context.Context, filePath string) ([]*Match, error) {
+	if s.fileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.fileTimeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		matches []*Match
+		err     error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		matches, err := s.scanFile(ctx, filePath)
+		done <- outcome{matches: matches, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.matches, out.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %v", s.fileTimeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// scanFile scans a single file for secrets until ctx is canceled
+func (s *Scanner) scanFile(ctx context.Context, filePath string) ([]*Match, error) {
+	if s.archives && isArchive(filePath) {
+		return s.scanArchive(ctx, filePath)
+	}
 
-	file, err := os.Open(filePath)
+	file, err := s.open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	// Closing the file unblocks a read in progress when the scan is canceled
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	// Sniff the start of the file so binaries with unknown extensions are skipped
+	reader := bufio.NewReaderSize(file, binarySniffSize)
+	head, err := reader.Peek(binarySniffSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if utils.IsBinaryContent(head) {
+		return nil, errBinaryContent
+	}
+	if s.isGenerated(head) {
+		return nil, errGeneratedContent
+	}
+
+	return s.scanReader(ctx, reader, filePath)
+}
+
+// scanReader scans content line by line, reporting matches under the given file name.
+// It stops with ctx's error once ctx is canceled.
+func (s *Scanner) scanReader(ctx context.Context, r io.Reader, filePath string) ([]*Match, error) {
+	var (
+		matches   []*Match
+		matchTime time.Duration
+		started   time.Time
+	)
+	if s.stats != nil {
+		r = &countingReader{r: r, n: &s.stats.bytes}
+		defer func() { s.stats.matchTime.Add(int64(matchTime)) }()
+	}
+
+	activePatterns := s.patterns
+	scanner := bufio.NewScanner(r)
+	split, partial := splitLines(s.maxLineLen)
+	scanner.Buffer(make([]byte, 0, min(64*1024, s.maxLineLen)), s.maxLineLen)
+	scanner.Split(split)
 	lineNumber := 0
 
+	// kept counts the single-line matches that survive dedup, as those are what the per-file limit
+	// applies to; dropped is set once one is left out past the limit
+	var (
+		kept        int
+		dropped     bool
+		lineSecrets map[string]bool
+	)
+	add := func(match *Match) {
+		if s.dedup {
+			if lineSecrets[match.MatchText] {
+				matches = append(matches, match)
+				return
+			}
+		}
+		if s.maxMatches > 0 && kept >= s.maxMatches {
+			dropped = true
+			return
+		}
+		if s.dedup {
+			lineSecrets[match.MatchText] = true
+		}
+		kept++
+		matches = append(matches, match)
+	}
+
+	var lines []string
+	line := ""
+	continued := false
 	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		prevLine := line
+		line = scanner.Text()
 
-		// Skip empty lines
-		if strings.TrimSpace(line) == "" {
+		// A piece of an overlong line keeps the line's number, and lines holds the line whole.
+		// offset is where the piece starts in the line, so columns count from the start of the line.
+		if continued {
+			lines[len(lines)-1] += line
+		} else {
+			lineNumber++
+			lines = append(lines, line)
+		}
+		continued = *partial
+		offset := len(lines[len(lines)-1]) - len(line)
+
+		if s.dedup && offset == 0 {
+			lineSecrets = make(map[string]bool)
+		}
+
+		// Skip empty lines, and every line once matches were dropped: later lines can only add more.
+		// Lines are still read so multi-line patterns see the whole file.
+		if strings.TrimSpace(line) == "" || dropped {
 			continue
 		}
 
-		// Check against all patterns
-		for _, pattern := range patterns.GetPatterns() {
-			if pattern.Regex.MatchString(line) {
-				match := &Match{
-					FilePath:    filePath,
-					LineNumber:  lineNumber,
-					MatchText:   pattern.Regex.FindString(line),
-					Pattern:     &pattern,
-					LineContent: line,
-				}
-				matches = append(matches, match)
+		// Check against all patterns, indexing so each match points at its own pattern.
+		// Patterns the prefilter rules out for this line could not match it.
+		lineMatched := false
+		if s.stats != nil {
+			started = time.Now()
+		}
+		candidate := s.filter.candidate(line)
+		for i := range activePatterns {
+			pattern := &activePatterns[i]
+			if pattern.MultiLine || (!candidate && s.filter.skip(i)) {
+				continue
+			}
+
+			// A matched line explains itself even when suppressed, so entropy won't re-flag it
+			secret, start, matched, keep := s.lineSecret(pattern, line, prevLine)
+			lineMatched = lineMatched || matched
+			if !keep {
+				continue
+			}
+
+			match := &Match{
+				FilePath:    filePath,
+				LineNumber:  lineNumber,
+				MatchText:   secret,
+				Pattern:     pattern,
+				LineContent: line,
+			}
+			match.Column, match.EndColumn = columns(lines[len(lines)-1], offset+start, offset+start+len(secret))
+			add(match)
+		}
+		if s.stats != nil {
+			matchTime += time.Since(started)
+		}
+
+		// Only fall back to entropy when no named pattern explains the line
+		if s.entropy > 0 && !lineMatched && !ignoredInline(&patterns.HighEntropyPattern, line, prevLine) {
+			for _, match := range s.entropyMatches(filePath, lineNumber, line) {
+				// Entropy columns are counted from the start of the piece, so shift them to the whole line
+				prefix := utf8.RuneCountInString(lines[len(lines)-1][:offset])
+				match.Column += prefix
+				match.EndColumn += prefix
+				add(match)
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 
+	if s.stats != nil {
+		started = time.Now()
+	}
+	matches = multiLineMatches(filePath, lines, activePatterns, matches)
+	if s.multiAssign {
+		matches = s.continuationMatches(filePath, lines, activePatterns, matches)
+	}
+	if s.stats != nil {
+		matchTime += time.Since(started)
+	}
+	if s.dedup {
+		matches = dedupMatches(matches)
+	}
+	if s.maxMatches > 0 && len(matches) > s.maxMatches {
+		matches = matches[:s.maxMatches]
+		dropped = true
+	}
+	if dropped && len(matches) > 0 {
+		matches[len(matches)-1].capped = true
+	}
+	if s.contextLines > 0 {
+		addContext(matches, lines, s.contextLines)
+	}
 	return matches, nil
 }
 
+// cappedFiles returns the files, or archive entries, among matches from one scanned file that had
+// matches dropped at the per-file match limit
+func (s *Scanner) cappedFiles(matches []*Match) []string {
+	if s.maxMatches <= 0 {
+		return nil
+	}
+
+	var capped []string
+	for _, match := range matches {
+		if match.capped {
+			capped = append(capped, match.FilePath)
+		}
+	}
+	return capped
+}
+
+// splitLines splits like bufio.ScanLines, except that a line longer than max bytes is returned in
+// pieces of max bytes instead of failing the scan. partial reports whether the last token was such a piece.
+func splitLines(max int) (split bufio.SplitFunc, partial *bool) {
+	partial = new(bool)
+	split = func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if advance > 0 || err != nil || len(data) < max {
+			*partial = false
+			return advance, token, err
+		}
+
+		*partial = true
+		return max, data[:max], nil
+	}
+	return split, partial
+}
+
+// addContext copies up to n lines before and after each match from the file's lines
+func addContext(matches []*Match, lines []string, n int) {
+	for _, match := range matches {
+		index := match.LineNumber - 1
+
+		start := index - n
+		if start < 0 {
+			start = 0
+		}
+		end := index + 1 + n
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		match.ContextBefore = append([]string(nil), lines[start:index]...)
+		match.ContextAfter = append([]string(nil), lines[index+1:end]...)
+	}
+}
+
+// multiLineMatches runs multi-line patterns over the whole file. Each block becomes one finding
+// reported on its first line, and entropy findings inside the block are dropped as part of it.
+func multiLineMatches(filePath string, lines []string, activePatterns []patterns.Pattern, matches []*Match) []*Match {
+	content := strings.Join(lines, "\n")
+
+	type span struct {
+		start, end int
+	}
+	var spans []span
+
+	for i := range activePatterns {
+		pattern := &activePatterns[i]
+		if !pattern.MultiLine {
+			continue
+		}
+
+		for _, loc := range pattern.Regex.FindAllStringSubmatchIndex(content, -1) {
+			startLine := strings.Count(content[:loc[0]], "\n") + 1
+			endLine := startLine + strings.Count(content[loc[0]:loc[1]], "\n")
+
+			prevLine := ""
+			if startLine > 1 {
+				prevLine = lines[startLine-2]
+			}
+			if ignoredInline(pattern, lines[startLine-1], prevLine) {
+				continue
+			}
+
+			start, end := secretSpan(pattern, loc)
+			match := &Match{
+				FilePath:    filePath,
+				LineNumber:  startLine,
+				MatchText:   content[start:end],
+				Pattern:     pattern,
+				LineContent: lines[startLine-1],
+			}
+
+			// A secret on the reported line gets its own columns, otherwise the column is where the block starts
+			lineStart := strings.LastIndex(content[:loc[0]], "\n") + 1
+			if !strings.Contains(content[loc[0]:end], "\n") {
+				match.Column, match.EndColumn = columns(content[lineStart:], start-lineStart, end-lineStart)
+			} else {
+				match.Column, _ = columns(content[lineStart:], loc[0]-lineStart, loc[0]-lineStart)
+			}
+			matches = append(matches, match)
+			spans = append(spans, span{start: startLine, end: endLine})
+		}
+	}
+
+	if len(spans) == 0 {
+		return matches
+	}
+
+	kept := matches[:0]
+	for _, match := range matches {
+		inside := false
+		if match.Pattern == &patterns.HighEntropyPattern {
+			for _, sp := range spans {
+				if match.LineNumber >= sp.start && match.LineNumber <= sp.end {
+					inside = true
+					break
+				}
+			}
+		}
+		if !inside {
+			kept = append(kept, match)
+		}
+	}
+
+	return kept
+}
+
+// dedupMatches keeps one match per line and secret value. The highest-severity pattern wins, and
+// between equal severities the pattern listed first, which is the more specific one.
+func dedupMatches(matches []*Match) []*Match {
+	type key struct {
+		line   int
+		secret string
+	}
+
+	kept := make(map[key]int)
+	deduped := make([]*Match, 0, len(matches))
+	for _, match := range matches {
+		k := key{match.LineNumber, match.MatchText}
+		i, seen := kept[k]
+		if !seen {
+			kept[k] = len(deduped)
+			deduped = append(deduped, match)
+			continue
+		}
+
+		if patterns.SeverityRank(match.Pattern.Severity) > patterns.SeverityRank(deduped[i].Pattern.Severity) {
+			deduped[i] = match
+		}
+	}
+	return deduped
+}
+
+// lineSecret matches a single-line pattern against line. start is the byte offset of the secret in line.
+// matched reports whether the regex matched at all, and keep whether the secret survives inline ignores,
+// verification and the keyword check.
+func (s *Scanner) lineSecret(pattern *patterns.Pattern, line, prevLine string) (secret string, start int, matched, keep bool) {
+	loc := pattern.Regex.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return "", 0, false, false
+	}
+
+	if ignoredInline(pattern, line, prevLine) {
+		return "", 0, true, false
+	}
+
+	start, end := secretSpan(pattern, loc)
+	secret = line[start:end]
+	if (s.verify || pattern.AlwaysValidate) && pattern.Validate != nil && !pattern.Validate(secret) {
+		return "", 0, true, false
+	}
+	if !s.keywordNear(pattern, line, start, len(secret)) {
+		return "", 0, true, false
+	}
+	return secret, start, true, true
+}
+
+// secretSpan returns the byte offsets of the pattern's secret capture group in a match located by loc,
+// falling back to the whole match
+func secretSpan(pattern *patterns.Pattern, loc []int) (start, end int) {
+	g := pattern.SecretGroup
+	if g > 0 && 2*g+1 < len(loc) && loc[2*g] >= 0 && loc[2*g] < loc[2*g+1] {
+		return loc[2*g], loc[2*g+1]
+	}
+	return loc[0], loc[1]
+}
+
+// columns converts the byte offsets of a secret in line to 1-based rune columns, the end being just past it
+func columns(line string, start, end int) (column, endColumn int) {
+	column = utf8.RuneCountInString(line[:start]) + 1
+	return column, column + utf8.RuneCountInString(line[start:end])
+}
+
+// entropyMatches flags random-looking tokens on a line
+func (s *Scanner) entropyMatches(filePath string, lineNumber int, line string) []*Match {
+	var matches []*Match
+
+	for _, loc := range patterns.HighEntropyPattern.Regex.FindAllStringIndex(line, -1) {
+		token := line[loc[0]:loc[1]]
+
+		// Long blobs and data URIs are embedded assets, typically in minified bundles
+		if len(token) > maxEntropyTokenLength || strings.HasSuffix(line[:loc[0]], "base64,") {
+			continue
+		}
+
+		// Identifiers in minified code rarely contain digits, generated secrets almost always do
+		if !strings.ContainsAny(token, "0123456789") {
+			continue
+		}
+
+		threshold := s.entropy
+		if isHex(token) {
+			threshold = threshold * 4 / 6
+		}
+
+		if utils.ShannonEntropy(token) < threshold {
+			continue
+		}
+
+		if !s.keywordNear(&patterns.HighEntropyPattern, line, loc[0], len(token)) {
+			continue
+		}
+
+		match := &Match{
+			FilePath:    filePath,
+			LineNumber:  lineNumber,
+			MatchText:   token,
+			Pattern:     &patterns.HighEntropyPattern,
+			LineContent: line,
+		}
+		match.Column, match.EndColumn = columns(line, loc[0], loc[1])
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
+// keywordNear reports whether a match of pattern starting at offset start on line may be kept under
+// the keyword proximity check. The secret itself is not searched, so a token can't vouch for itself.
+func (s *Scanner) keywordNear(pattern *patterns.Pattern, line string, start, length int) bool {
+	if s.proximity <= 0 || !pattern.RequireKeyword || start < 0 {
+		return true
+	}
+
+	end := start + length
+	window := strings.ToLower(line[max(0, start-s.proximity):start] + " " + line[end:min(len(line), end+s.proximity)])
+	for _, keyword := range s.keywords {
+		if strings.Contains(window, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHex reports whether s consists only of hexadecimal digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// included reports whether a file passes the include-only extension filter
+func (s *Scanner) included(filePath string) bool {
+	if len(s.includeExts) == 0 {
+		return true
+	}
+	return s.includeExts[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// excludedByGlob reports whether path, relative to root, matches an exclude pattern
+func (s *Scanner) excludedByGlob(root, path string) bool {
+	if len(s.excludeGlobs) == 0 {
+		return false
+	}
+
+	relPath := relativePath(root, path)
+	for _, regex := range s.excludeGlobs {
+		if regex.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
 // shouldSkipDir checks if a directory should be skipped
 func (s *Scanner) shouldSkipDir(dirName string) bool {
 	return s.excludeDirs[dirName]
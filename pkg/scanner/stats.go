@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ScanStats reports how long a scan took and where the time went, when enabled with SetCollectStats
+type ScanStats struct {
+	Duration  time.Duration // wall time of the scan, walking the tree included
+	Files     int           // files scanned
+	BytesRead int64         // content read from the scanned files
+	MatchTime time.Duration // time spent running patterns, summed over all workers
+}
+
+// FilesPerSecond returns the scan throughput in files
+func (st *ScanStats) FilesPerSecond() float64 {
+	if st.Duration <= 0 {
+		return 0
+	}
+	return float64(st.Files) / st.Duration.Seconds()
+}
+
+// BytesPerSecond returns the scan throughput in bytes
+func (st *ScanStats) BytesPerSecond() float64 {
+	if st.Duration <= 0 {
+		return 0
+	}
+	return float64(st.BytesRead) / st.Duration.Seconds()
+}
+
+// statsCounter accumulates the counters of a scan across workers
+type statsCounter struct {
+	bytes     atomic.Int64
+	matchTime atomic.Int64 // nanoseconds
+}
+
+// SetCollectStats enables collecting ScanStats, returned in ScanResult.Stats.
+// Counters are shared by the scanner, so scans collecting stats must not run concurrently.
+func (s *Scanner) SetCollectStats(enabled bool) {
+	if enabled {
+		s.stats = &statsCounter{}
+	} else {
+		s.stats = nil
+	}
+}
+
+// startStats resets the counters for a new scan and returns a function recording them in result
+func (s *Scanner) startStats() func(result *ScanResult) {
+	if s.stats == nil {
+		return func(*ScanResult) {}
+	}
+
+	s.stats.bytes.Store(0)
+	s.stats.matchTime.Store(0)
+	start := time.Now()
+	return func(result *ScanResult) {
+		result.Stats = &ScanStats{
+			Duration:  time.Since(start),
+			Files:     result.FilesScanned,
+			BytesRead: s.stats.bytes.Load(),
+			MatchTime: time.Duration(s.stats.matchTime.Load()),
+		}
+	}
+}
+
+// countingReader counts the bytes read through it into n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // FileExists checks if a file or directory exists
@@ -31,7 +37,8 @@ func GetAbsPath(path string) (string, error) {
 	return absPath, nil
 }
 
-// TruncateString truncates a string to a maximum length
+// TruncateString shortens s to at most maxLen bytes, ending in "...". It cuts at the last whole rune
+// that fits, so multibyte UTF-8 characters are never split, and drops whitespace left before the "...".
 func TruncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -39,7 +46,26 @@ func TruncateString(s string, maxLen int) string {
 	if maxLen <= 3 {
 		return "..."
 	}
-	return s[:maxLen-3] + "..."
+
+	cut := maxLen - 3
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strings.TrimRightFunc(s[:cut], unicode.IsSpace) + "..."
+}
+
+// RedactSecret masks a secret value, keeping up to four leading characters so it can still be recognised
+func RedactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	runes := []rune(s)
+	keep := 4
+	if len(runes) <= 2*keep {
+		keep = len(runes) / 4
+	}
+	return string(runes[:keep]) + "********"
 }
 
 // ContainsString checks if a string slice contains a value
@@ -85,11 +111,16 @@ func HighlightMatch(content string, match string) string {
 	return match
 }
 
-// ParseBytes parses a byte size string (e.g., "10MB", "1GB")
+// byteSize matches a size such as "10MB", "5.5 mb" or "1024"
+var byteSize = regexp.MustCompile(`^(\d+(?:\.\d+)?|\.\d+)\s*([KMGT]?B)?$`)
+
+// ParseBytes parses a byte size string (e.g., "10MB", "1GB", "5.5 MB" or a plain byte count).
+// Units are binary and case-insensitive, and fractional sizes are rounded to the nearest byte.
 func ParseBytes(s string) (int64, error) {
 	s = strings.ToUpper(strings.TrimSpace(s))
 
-	multipliers := map[string]int64{
+	multipliers := map[string]float64{
+		"":   1,
 		"B":  1,
 		"KB": 1024,
 		"MB": 1024 * 1024,
@@ -97,29 +128,22 @@ func ParseBytes(s string) (int64, error) {
 		"TB": 1024 * 1024 * 1024 * 1024,
 	}
 
-	for unit, multiplier := range multipliers {
-		if strings.HasSuffix(s, unit) {
-			numStr := strings.TrimSuffix(s, unit)
-			numStr = strings.TrimSpace(numStr)
-
-			var num int64
-			_, err := fmt.Sscanf(numStr, "%d", &num)
-			if err != nil {
-				return 0, fmt.Errorf("invalid size format: %s", s)
-			}
-
-			return num * multiplier, nil
-		}
+	parts := byteSize.FindStringSubmatch(s)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid size format: %s", s)
 	}
 
-	// Try parsing as plain number
-	var num int64
-	_, err := fmt.Sscanf(s, "%d", &num)
+	num, err := strconv.ParseFloat(parts[1], 64)
 	if err != nil {
 		return 0, fmt.Errorf("invalid size format: %s", s)
 	}
 
-	return num, nil
+	size := math.Round(num * multipliers[parts[2]])
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size too large: %s", s)
+	}
+
+	return int64(size), nil
 }
 
 // FormatBytes converts bytes to human-readable format
@@ -137,9 +161,10 @@ func FormatBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f TB", value)
 }
 
-// NormalizePathSeparators converts path separators to forward slashes
+// NormalizePathSeparators converts path separators to forward slashes. Backslashes are converted
+// on every OS so Windows paths normalize the same way everywhere.
 func NormalizePathSeparators(path string) string {
-	return strings.ReplaceAll(path, string(filepath.Separator), "/")
+	return strings.ReplaceAll(filepath.ToSlash(path), "\\", "/")
 }
 
 // IsTextFile checks if a file is likely a text file by examining its extension
@@ -191,6 +216,37 @@ func IsTextFile(filename string) bool {
 	return textExtensions[ext]
 }
 
+// IsBinaryContent reports whether data looks like binary content rather than text. Data holding a NUL
+// byte is binary, otherwise it is binary when over 30% of it is control characters or invalid UTF-8.
+func IsBinaryContent(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+
+	nonText := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// A rune cut off at the end of the buffer is not evidence of binary content
+			if len(data)-i < utf8.UTFMax && !utf8.FullRune(data[i:]) {
+				i = len(data)
+				continue
+			}
+			nonText++
+		case r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' && r != '\v' && r != 0x1b:
+			nonText++
+		}
+		i += size
+	}
+
+	return nonText*10 > len(data)*3
+}
+
 // GetRelativePath returns the relative path or the absolute path if relative path fails
 func GetRelativePath(targetPath, basePath string) string {
 	relPath, err := filepath.Rel(basePath, targetPath)
@@ -204,3 +260,80 @@ func GetRelativePath(targetPath, basePath string) string {
 func CleanPath(path string) string {
 	return filepath.Clean(path)
 }
+
+// ShannonEntropy returns the Shannon entropy of a string in bits per character
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+const (
+	base64Charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
+	hexCharset    = "0123456789abcdefABCDEF"
+)
+
+// Base64Entropy returns the Shannon entropy of the base64 characters in s, ignoring everything else.
+// Random base64 approaches 6 bits per character.
+func Base64Entropy(s string) float64 {
+	return ShannonEntropy(keepChars(s, base64Charset))
+}
+
+// HexEntropy returns the Shannon entropy of the hexadecimal characters in s, ignoring everything else.
+// Random hex approaches 4 bits per character.
+func HexEntropy(s string) float64 {
+	return ShannonEntropy(keepChars(s, hexCharset))
+}
+
+// keepChars returns s with every character outside charset removed
+func keepChars(s, charset string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(charset, r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// LuhnValid reports whether number passes the Luhn checksum used by payment card numbers.
+// Spaces and dashes between digit groups are ignored; any other non-digit fails the check.
+func LuhnValid(number string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits > 1 && sum%10 == 0
+}